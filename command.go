@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+)
+
+//runCommand Run the Subcommand, and Return the Exit Code
+func runCommand(name string, args []string) int {
+	switch name {
+	case "recompute-scores":
+		return recomputeScores(args)
+	case "import-bundles":
+		return importBundles(args)
+	case "export-bundles":
+		return exportBundles(args)
+	}
+	fmt.Fprintf(os.Stderr, "unknown command: %s\n", name)
+	return 2
+}
+
+func recomputeScores(args []string) int {
+	flags := flag.NewFlagSet("recompute-scores", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report the differences without applying them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	report, err := model.RecomputeScores(*dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to recompute the scores: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("fixed hint penalties of %d found flags\n", report.FixedPenalties)
+	for _, diff := range report.Diffs {
+		kind := map[bool]string{true: "team", false: "user"}[diff.IsTeam]
+		fmt.Printf("%s %s (%s): %d -> %d\n", kind, diff.ID, diff.Name, diff.Stored, diff.Recomputed)
+	}
+	if *dryRun {
+		fmt.Println("dry run: nothing was applied")
+	}
+	return 0
+}
+
+func importBundles(args []string) int {
+	flags := flag.NewFlagSet("import-bundles", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report the drift without applying it")
+	confirm := flags.Bool("confirm", false, "apply the updates even if they affect users who have already opened the hints, found the flags or solved the challenges")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import-bundles [-dry-run] [-confirm] <dir>")
+		return 2
+	}
+
+	report, err := model.ImportBundles(flags.Arg(0), *dryRun, *confirm, "")
+	if report != nil {
+		for _, result := range report.Results {
+			fmt.Printf("%s %s/%s (%s)", result.Action, result.Genre, result.Name, result.Path)
+			if len(result.Drift) > 0 {
+				fmt.Printf(": %s", strings.Join(result.Drift, ", "))
+			}
+			fmt.Println()
+			for _, impact := range result.Impacts {
+				fmt.Printf("  %s %s %s: %d users\n", impact.Action, impact.Kind, impact.ID, impact.Users)
+			}
+		}
+	}
+	if err == model.ErrUnconfirmedEdit {
+		fmt.Fprintf(os.Stderr, "%v; run again with -confirm to apply the updates\n", err)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to import the bundles: %v\n", err)
+		return 1
+	}
+	if *dryRun {
+		fmt.Println("dry run: nothing was applied")
+	}
+	return 0
+}
+
+func exportBundles(args []string) int {
+	flags := flag.NewFlagSet("export-bundles", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: export-bundles <dir>")
+		return 2
+	}
+
+	n, err := model.ExportBundles(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to export the bundles: %v\n", err)
+		return 1
+	}
+	fmt.Printf("exported %d challenges\n", n)
+	return 0
+}
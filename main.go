@@ -18,6 +18,13 @@ func main() {
 		return
 	}
 	defer model.TermDB()
+	if len(os.Args) > 1 {
+		if code := runCommand(os.Args[1], os.Args[2:]); code != 0 {
+			model.TermDB()
+			os.Exit(code)
+		}
+		return
+	}
 	if err := model.InitWebShellCli(); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to init Web Shell Client: %v\n", err)
 	}
@@ -42,13 +49,31 @@ func main() {
 	g.POST("/challenges", router.PostChallenge, router.EnsureIAmAuthor)
 	g.PUT("/challenges/:challengeID", router.PutChallenge, router.EnsureIAmAuthor)
 	g.DELETE("/challenges/:challengeID", router.DeleteChallenge, router.EnsureIAmAuthor)
-	g.POST("/challenges/:challengeID", router.CheckAnswer, router.EnsureIExist, router.EnsureContestStarted, router.EnsureContestNotFinished)
+	g.POST("/challenges/:challengeID", router.CheckAnswer, router.EnsureIExist, router.EnsureContestStarted, router.EnsureContestNotFinished, router.EnsureNotRateLimited)
+	g.GET("/challenges/:challengeID/flags/:userID", router.GetUserFlags, router.EnsureIAmAuthor)
+	g.POST("/challenges/:challengeID/files", router.PostAttachment, router.EnsureIAmAuthor)
+	g.GET("/challenges/:challengeID/files/:fileID", router.GetAttachment, router.EnsureContestStarted)
+	g.POST("/challenges/:challengeID/hints/:hintID/unlock", router.UnlockHint, router.EnsureIExist, router.EnsureContestStarted)
+	g.GET("/challenges/:challengeID/revisions", router.GetRevisions, router.EnsureIAmAuthor)
+	g.POST("/challenges/:challengeID/revisions/:revisionID/rollback", router.RollbackChallenge, router.EnsureIAmAuthor)
 	g.GET("/challenges/:challengeID/votes/:userID", router.GetVote, router.EnsureIExist)
 	g.PUT("/challenges/:challengeID/votes/:userID", router.PutVote, router.EnsureIExist, router.EnsureContestStarted)
+	g.GET("/submissions", router.GetSubmissions, router.EnsureIAmAuthor)
+	g.GET("/reports/cheating", router.GetCheatingReport, router.EnsureIAmAuthor)
+	g.GET("/announcements", router.GetAnnouncements)
+	g.GET("/announcements/:announcementID", router.GetAnnouncement)
+	g.POST("/announcements", router.PostAnnouncement, router.EnsureIAmAuthor)
+	g.PUT("/announcements/:announcementID", router.PutAnnouncement, router.EnsureIAmAuthor)
+	g.DELETE("/announcements/:announcementID", router.DeleteAnnouncement, router.EnsureIAmAuthor)
 	g.GET("/questions", router.GetQuestions)
+	g.GET("/questions/queue", router.GetQuestionQueue, router.EnsureIAmAuthor)
 	g.GET("/questions/:questionID", router.GetQuestion)
 	g.POST("/questions", router.PostQuestion, router.EnsureIExist, router.EnsureContestStarted, router.EnsureContestNotFinished)
 	g.PUT("/questions/:questionID", router.PutQuestion, router.EnsureIAmAuthor)
+	g.POST("/questions/:questionID/messages", router.PostQuestionMessage, router.EnsureIExist, router.EnsureContestStarted, router.EnsureContestNotFinished)
+	g.POST("/questions/:questionID/close", router.CloseQuestion, router.EnsureIExist)
+	g.POST("/questions/:questionID/claim", router.ClaimQuestion, router.EnsureIAmAuthor)
+	g.DELETE("/questions/:questionID/claim", router.UnclaimQuestion, router.EnsureIAmAuthor)
 	g.GET("/users", router.GetUsers)
 	g.GET("/users/:userID", router.GetUser)
 	g.GET("/users/me", router.GetMe, router.EnsureIExist)
@@ -56,6 +81,23 @@ func main() {
 	g.GET("/users/:userID/solved", router.GetSolvedChallenges)
 	g.GET("/users/:userID/solved/last", router.GetLastSolvedChallenge)
 	g.GET("/users/:userID/lastseen", router.GetLastSeenChallenge)
+	g.GET("/scoreboard/timeline", router.GetScoreTimelines)
+	g.GET("/ranking", router.GetRanking)
+	g.GET("/export/ctftime", router.ExportCTFtime)
+	g.GET("/export/standings", router.ExportStandings, router.EnsureIAmAuthor)
+	g.POST("/admin/unfreeze", router.Unfreeze, router.EnsureIAmAuthor)
+	g.POST("/admin/recompute-scores", router.RecomputeScores, router.EnsureIAmAuthor)
+	g.GET("/admin/bundles", router.ExportBundles, router.EnsureIAmAuthor)
+	g.POST("/admin/bundles", router.ImportBundles, router.EnsureIAmAuthor)
+	g.GET("/admin/audit-logs", router.GetAuditLogs, router.EnsureIAmAuthor)
+	g.GET("/trash/challenges", router.GetTrashedChallenges, router.EnsureIAmAuthor)
+	g.POST("/trash/challenges/:challengeID/restore", router.RestoreChallenge, router.EnsureIAmAuthor)
+	g.DELETE("/trash/challenges/:challengeID", router.PurgeChallenge, router.EnsureIAmAuthor)
+	g.GET("/teams", router.GetTeams, router.EnsureTeamMode)
+	g.GET("/teams/:teamID", router.GetTeam, router.EnsureTeamMode)
+	g.POST("/teams", router.PostTeam, router.EnsureTeamMode, router.EnsureIExist, router.EnsureContestNotFinished)
+	g.POST("/teams/:teamID/members", router.JoinTeam, router.EnsureTeamMode, router.EnsureIExist, router.EnsureContestNotFinished)
+	g.DELETE("/teams/:teamID/members/:userID", router.LeaveTeam, router.EnsureTeamMode, router.EnsureIExist, router.EnsureContestNotFinished)
 	//g.GET("/visualizer", router.Visualizer.Handler())
 
 	e.Static("/", "view/")
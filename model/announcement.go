@@ -0,0 +1,118 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/satori/go.uuid"
+)
+
+//Announcement an Announcement Record, which is a Notice from the Authors to Everyone
+type Announcement struct {
+	ID          string `gorm:"primary_key"`
+	AuthorID    string
+	Author      *User `gorm:"foreignkey:AuthorID"`
+	ChallengeID string
+	Challenge   *Challenge `gorm:"foreignkey:ChallengeID"`
+	Title       string
+	Body        string `sql:"type:text;"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+}
+
+//ErrAnnouncementNotFound an Error due to the Announcement Not Found
+var ErrAnnouncementNotFound = gorm.ErrRecordNotFound
+
+//ErrInvalidAnnouncement an Error due to an Announcement without a Title or about an Unknown Challenge
+var ErrInvalidAnnouncement = fmt.Errorf("invalid announcement")
+
+//GetAnnouncements Get All Announcement Records, the Newest First
+func GetAnnouncements() ([]*Announcement, error) {
+	announcements := make([]*Announcement, 0)
+	if err := db.Preload("Author").Preload("Challenge", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Order("created_at desc").Find(&announcements).Error; err != nil {
+		return nil, err
+	}
+	return announcements, nil
+}
+
+//GetAnnouncementByID Get the Announcement Record by its ID
+func GetAnnouncementByID(id string) (*Announcement, error) {
+	announcement := &Announcement{}
+	if err := db.Where(&Announcement{ID: id}).Preload("Author").Preload("Challenge", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).First(announcement).Error; err != nil {
+		return nil, err
+	}
+	return announcement, nil
+}
+
+//findAnnouncementChallenge Find the Challenge the Announcement is about, if any
+func findAnnouncementChallenge(challengeID string) (*Challenge, error) {
+	if challengeID == "" {
+		return nil, nil
+	}
+	challenge := &Challenge{}
+	if err := db.Where(&Challenge{ID: challengeID}).First(challenge).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidAnnouncement
+		}
+		return nil, err
+	}
+	return challenge, nil
+}
+
+//NewAnnouncement Make a New Announcement Record about the Challenge (Empty means the Whole Contest)
+func NewAnnouncement(authorID string, challengeID string, title string, body string) (*Announcement, error) {
+	if title == "" {
+		return nil, ErrInvalidAnnouncement
+	}
+	author, err := GetUserByID(authorID, false)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := findAnnouncementChallenge(challengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	announcement := &Announcement{
+		ID:          uuid.NewV4().String(),
+		AuthorID:    authorID,
+		Author:      author,
+		ChallengeID: challengeID,
+		Challenge:   challenge,
+		Title:       title,
+		Body:        body,
+	}
+	if err := db.Create(announcement).Error; err != nil {
+		return nil, err
+	}
+	return announcement, nil
+}
+
+//Update Update the Announcement Record
+func (announcement *Announcement) Update(challengeID string, title string, body string) error {
+	if title == "" {
+		return ErrInvalidAnnouncement
+	}
+	challenge, err := findAnnouncementChallenge(challengeID)
+	if err != nil {
+		return err
+	}
+	announcement.ChallengeID, announcement.Challenge, announcement.Title, announcement.Body = challengeID, challenge, title, body
+	return db.Save(announcement).Error
+}
+
+//Delete Delete the Announcement Record
+func (announcement *Announcement) Delete() error {
+	return db.Delete(announcement).Error
+}
+
+//IsVisibleTo Whether the User can See the Announcement, which is Hidden until the Challenge it is about is Released, and after it is Deleted
+func (announcement *Announcement) IsVisibleTo(user *User, t time.Time) bool {
+	if user.IsAuthor || announcement.ChallengeID == "" {
+		return true
+	}
+	challenge := announcement.Challenge
+	return challenge != nil && challenge.DeletedAt == nil && challenge.IsReleased(t)
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/satori/go.uuid"
+)
+
+//Attachment a Record of a File Attached to a Challenge
+type Attachment struct {
+	ID          string `gorm:"primary_key"`
+	ChallengeID string
+	Name        string
+	ContentType string
+	Size        int64
+	SHA256      string
+	CreatedAt   time.Time
+}
+
+//ErrAttachmentNotFound an Error due to the Attachment Not Found
+var ErrAttachmentNotFound = gorm.ErrRecordNotFound
+
+//FileStorage a Store of the Attachment Files, which may be Shared among Multiple Instances
+type FileStorage interface {
+	//Put Store the Content as the Key
+	Put(key string, content io.Reader) error
+	//Get Open the Content Stored as the Key
+	Get(key string) (io.ReadCloser, error)
+	//Delete Delete the Content Stored as the Key
+	Delete(key string) error
+}
+
+//LocalFileStorage a FileStorage which Keeps the Files in a Directory on the Local Disk
+type LocalFileStorage struct {
+	Dir string
+}
+
+//NewLocalFileStorage Make a New LocalFileStorage
+func NewLocalFileStorage(dir string) *LocalFileStorage {
+	return &LocalFileStorage{
+		Dir: dir,
+	}
+}
+
+//Put Store the Content as the Key
+func (storage *LocalFileStorage) Put(key string, content io.Reader) error {
+	if err := os.MkdirAll(storage.Dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(storage.Dir, filepath.Base(key))
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+//Get Open the Content Stored as the Key
+func (storage *LocalFileStorage) Get(key string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(storage.Dir, filepath.Base(key)))
+}
+
+//Delete Delete the Content Stored as the Key
+func (storage *LocalFileStorage) Delete(key string) error {
+	return os.Remove(filepath.Join(storage.Dir, filepath.Base(key)))
+}
+
+var fileStorage FileStorage = NewLocalFileStorage(AttachmentDir())
+
+//SetFileStorage Replace the Store used for the Attachment Files
+func SetFileStorage(storage FileStorage) {
+	fileStorage = storage
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+//AddAttachment Store the File and Attach it to the Challenge
+func (challenge *Challenge) AddAttachment(name string, contentType string, content io.Reader) (*Attachment, error) {
+	return challenge.addAttachment(db, name, contentType, content)
+}
+
+//addAttachment Store the File and Attach it to the Challenge in the Transaction, whose Rollback Leaves the File to be Deleted by the Caller
+func (challenge *Challenge) addAttachment(tx *gorm.DB, name string, contentType string, content io.Reader) (*Attachment, error) {
+	id := uuid.NewV4().String()
+	hash, counter := sha256.New(), &countingWriter{}
+	if err := fileStorage.Put(id, io.TeeReader(content, io.MultiWriter(hash, counter))); err != nil {
+		return nil, err
+	}
+
+	attachment := &Attachment{
+		ID:          id,
+		ChallengeID: challenge.ID,
+		Name:        filepath.Base(name),
+		ContentType: contentType,
+		Size:        counter.n,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}
+	if err := tx.Create(attachment).Error; err != nil {
+		fileStorage.Delete(id)
+		return nil, err
+	}
+	challenge.Attachments = append(challenge.Attachments, attachment)
+	return attachment, nil
+}
+
+//DeleteAttachment Delete the Attachment of the Challenge and its File
+func (challenge *Challenge) DeleteAttachment(attachment *Attachment) error {
+	if err := challenge.detachAttachment(db, attachment); err != nil {
+		return err
+	}
+	return fileStorage.Delete(attachment.ID)
+}
+
+//detachAttachment Delete the Attachment of the Challenge in the Transaction, Leaving its File to be Deleted by the Caller after the Commit
+func (challenge *Challenge) detachAttachment(tx *gorm.DB, attachment *Attachment) error {
+	if err := tx.Delete(attachment).Error; err != nil {
+		return err
+	}
+	for i, a := range challenge.Attachments {
+		if a.ID == attachment.ID {
+			challenge.Attachments = append(challenge.Attachments[:i], challenge.Attachments[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+//GetAttachment Get the Attachment of the Challenge
+func (challenge *Challenge) GetAttachment(id string) (*Attachment, error) {
+	attachment := &Attachment{}
+	if err := db.Where(&Attachment{ID: id, ChallengeID: challenge.ID}).First(attachment).Error; err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+//Open Open the File of the Attachment
+func (attachment *Attachment) Open() (io.ReadCloser, error) {
+	return fileStorage.Get(attachment.ID)
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//Actions Recorded in the Audit Log
+const (
+	AuditTrashChallenge   = "trash_challenge"
+	AuditRestoreChallenge = "restore_challenge"
+	AuditPurgeChallenge   = "purge_challenge"
+)
+
+//AuditLog a Record of an Administrative Action
+type AuditLog struct {
+	ID        int `gorm:"primary_key"`
+	ActorID   string
+	Actor     *User `gorm:"foreignkey:ActorID"`
+	Action    string
+	TargetID  string
+	Detail    string `sql:"type:text;"`
+	CreatedAt time.Time
+}
+
+//recordAudit Record the Action in the Audit Log
+func recordAudit(tx *gorm.DB, actorID string, action string, targetID string, detail string) error {
+	return tx.Create(&AuditLog{
+		ActorID:  actorID,
+		Action:   action,
+		TargetID: targetID,
+		Detail:   detail,
+	}).Error
+}
+
+//GetAuditLogs Get the Audit Log Records from the Newest
+func GetAuditLogs() ([]*AuditLog, error) {
+	logs := make([]*AuditLog, 0)
+	if err := db.Preload("Actor").Order("id desc").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
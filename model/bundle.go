@@ -0,0 +1,884 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"gopkg.in/yaml.v2"
+)
+
+//BundleFileName the Name of the File Describing a Challenge in a Bundle Directory
+const BundleFileName = "challenge.yml"
+
+//Bundle a Challenge in the Portable Format, which is Stored as a Directory with challenge.yml and the Attachment Files
+type Bundle struct {
+	ID            string                `yaml:"id,omitempty"`
+	Genre         string                `yaml:"genre"`
+	Name          string                `yaml:"name"`
+	Author        string                `yaml:"author"`
+	AuthorID      string                `yaml:"author_id,omitempty"`
+	Score         int                   `yaml:"score"`
+	ScoringMode   string                `yaml:"scoring_mode"`
+	MinimumScore  int                   `yaml:"minimum_score,omitempty"`
+	Decay         int                   `yaml:"decay,omitempty"`
+	Bonuses       []int                 `yaml:"bonuses,flow,omitempty"`
+	Caption       string                `yaml:"caption"`
+	Hints         []*BundleHint         `yaml:"hints,omitempty"`
+	Flags         []*BundleFlag         `yaml:"flags"`
+	FlagFormat    string                `yaml:"flag_format,omitempty"`
+	Answer        string                `yaml:"answer,omitempty"`
+	Prerequisites []*BundlePrerequisite `yaml:"prerequisites,omitempty"`
+	ReleaseAt     *time.Time            `yaml:"release_at,omitempty"`
+	HideAt        *time.Time            `yaml:"hide_at,omitempty"`
+	Files         []string              `yaml:"files,omitempty"`
+	dir           string
+}
+
+//BundleHint a Hint in the Portable Format
+type BundleHint struct {
+	ID        string     `yaml:"id,omitempty"`
+	Caption   string     `yaml:"caption"`
+	Penalty   int        `yaml:"penalty"`
+	Cost      int        `yaml:"cost,omitempty"`
+	ReleaseAt *time.Time `yaml:"release_at,omitempty"`
+}
+
+//BundleFlag a Flag in the Portable Format
+type BundleFlag struct {
+	ID        string `yaml:"id,omitempty"`
+	Flag      string `yaml:"flag"`
+	Score     int    `yaml:"score"`
+	MatchMode string `yaml:"match_mode"`
+	Secret    string `yaml:"secret,omitempty"`
+}
+
+//BundlePrerequisite a Prerequisite in the Portable Format, which Refers to the Required Challenge by its Genre and Name, and also by its ID in a Snapshot
+type BundlePrerequisite struct {
+	Challenge      string `yaml:"challenge,omitempty"`
+	ChallengeGenre string `yaml:"challenge_genre,omitempty"`
+	ChallengeID    string `yaml:"challenge_id,omitempty"`
+	Genre          string `yaml:"genre,omitempty"`
+	Score          int    `yaml:"score,omitempty"`
+}
+
+//challengeKey the Genre and the Name by which Bundles Refer to a Challenge, where the Genre is Empty for the Name Only
+type challengeKey struct {
+	Genre string
+	Name  string
+}
+
+//ErrAmbiguousChallenge an Error due to a Prerequisite which Refers Only by the Name to One of the Challenges Sharing it
+var ErrAmbiguousChallenge = fmt.Errorf("ambiguous challenge name; set challenge_genre as well")
+
+//addChallengeKey Register the ID of the Challenge by its Genre and Name, and by its Name Only, which Becomes Ambiguous if Another Challenge has the Name
+func addChallengeKey(ids map[challengeKey]string, genre string, name string, id string) {
+	ids[challengeKey{Genre: genre, Name: name}] = id
+	key := challengeKey{Name: name}
+	if other, ok := ids[key]; ok && other != id {
+		ids[key] = ""
+	} else {
+		ids[key] = id
+	}
+}
+
+//requiredID Find the ID of the Challenge Required by the Prerequisite, by its Genre and Name, or by its Name Only unless it is Ambiguous
+func (prerequisite *BundlePrerequisite) requiredID(ids map[challengeKey]string) (string, error) {
+	id, ok := ids[challengeKey{Genre: prerequisite.ChallengeGenre, Name: prerequisite.Challenge}]
+	if !ok {
+		return "", fmt.Errorf("required challenge %q: %v", prerequisite.Challenge, ErrChallengeNotFound)
+	}
+	if id == "" {
+		return "", fmt.Errorf("required challenge %q: %v", prerequisite.Challenge, ErrAmbiguousChallenge)
+	}
+	return id, nil
+}
+
+//Import Actions of a Bundle
+const (
+	BundleCreate    = "create"
+	BundleUpdate    = "update"
+	BundleUnchanged = "unchanged"
+)
+
+//BundleResult the Result of Importing a Bundle
+type BundleResult struct {
+	Path    string
+	ID      string
+	Genre   string
+	Name    string
+	Action  string
+	Drift   []string
+	Impacts []*EditImpact
+}
+
+//ImportReport the Result of Importing Bundles
+type ImportReport struct {
+	DryRun  bool
+	Results []*BundleResult
+}
+
+//normalize Fill the Default Values so that Equivalent Bundles become Equal
+func (bundle *Bundle) normalize() {
+	if bundle.ScoringMode == "" {
+		bundle.ScoringMode = ScoringStatic
+	}
+	if len(bundle.Bonuses) == 0 {
+		bundle.Bonuses = nil
+	}
+	if len(bundle.Hints) == 0 {
+		bundle.Hints = nil
+	}
+	if len(bundle.Flags) == 0 {
+		bundle.Flags = nil
+	}
+	if len(bundle.Prerequisites) == 0 {
+		bundle.Prerequisites = nil
+	}
+	for _, _flag := range bundle.Flags {
+		if _flag.MatchMode == "" {
+			_flag.MatchMode = FlagExact
+		}
+	}
+	times := []**time.Time{&bundle.ReleaseAt, &bundle.HideAt}
+	for _, hint := range bundle.Hints {
+		times = append(times, &hint.ReleaseAt)
+	}
+	for _, t := range times {
+		if *t != nil {
+			utc := (*t).UTC().Truncate(time.Second)
+			*t = &utc
+		}
+	}
+}
+
+//newBundle Make the Bundle of the Challenge, Referring to the Required Challenges, which are Keyed by their IDs, by their Genres and Names
+func newBundle(challenge *Challenge, required map[string]*Challenge) *Bundle {
+	bundle := &Bundle{
+		ID:           challenge.ID,
+		Genre:        challenge.Genre,
+		Name:         challenge.Name,
+		Score:        challenge.Score,
+		ScoringMode:  challenge.ScoringMode,
+		MinimumScore: challenge.MinimumScore,
+		Decay:        challenge.Decay,
+		Bonuses:      challenge.BonusPercents(),
+		Caption:      challenge.Caption,
+		FlagFormat:   challenge.FlagFormat,
+		Answer:       challenge.Answer,
+		ReleaseAt:    challenge.ReleaseAt,
+		HideAt:       challenge.HideAt,
+	}
+	if challenge.Author != nil {
+		bundle.Author, bundle.AuthorID = challenge.Author.Name, challenge.AuthorID
+	}
+	hints := append([]*Hint{}, challenge.Hints...)
+	sort.SliceStable(hints, func(i, j int) bool { return hints[i].Position < hints[j].Position })
+	for _, hint := range hints {
+		bundle.Hints = append(bundle.Hints, &BundleHint{
+			ID:        hint.ID,
+			Caption:   hint.Caption,
+			Penalty:   hint.PenaltyPercent,
+			Cost:      hint.Cost,
+			ReleaseAt: hint.ReleaseAt,
+		})
+	}
+	flags := append([]*Flag{}, challenge.Flags...)
+	sort.SliceStable(flags, func(i, j int) bool { return flags[i].Position < flags[j].Position })
+	for _, _flag := range flags {
+		bundle.Flags = append(bundle.Flags, &BundleFlag{
+			ID:        _flag.ID,
+			Flag:      _flag.Flag,
+			Score:     _flag.Score,
+			MatchMode: _flag.MatchMode,
+			Secret:    _flag.Secret,
+		})
+	}
+	for _, prerequisite := range challenge.Prerequisites {
+		bundlePrerequisite := &BundlePrerequisite{
+			ChallengeID: prerequisite.RequiredChallengeID,
+			Genre:       prerequisite.Genre,
+			Score:       prerequisite.GenreScore,
+		}
+		if c, ok := required[prerequisite.RequiredChallengeID]; ok {
+			bundlePrerequisite.Challenge, bundlePrerequisite.ChallengeGenre = c.Name, c.Genre
+		}
+		bundle.Prerequisites = append(bundle.Prerequisites, bundlePrerequisite)
+	}
+	for _, attachment := range challenge.Attachments {
+		bundle.Files = append(bundle.Files, attachment.Name)
+	}
+	bundle.normalize()
+	return bundle
+}
+
+//portable Copy the Bundle with the IDs of the Author and the Required Challenges Cleared, which are Valid Only in this Server
+func (bundle *Bundle) portable() *Bundle {
+	copied := *bundle
+	copied.AuthorID, copied.Prerequisites = "", nil
+	for _, prerequisite := range bundle.Prerequisites {
+		p := *prerequisite
+		p.ChallengeID = ""
+		copied.Prerequisites = append(copied.Prerequisites, &p)
+	}
+	return &copied
+}
+
+//withoutItemIDs Copy the Bundle with the IDs of the Hints, the Flags, the Author and the Required Challenges Cleared, to Compare only the Contents
+func (bundle *Bundle) withoutItemIDs() *Bundle {
+	copied := *bundle.portable()
+	copied.Hints, copied.Flags = nil, nil
+	for _, hint := range bundle.Hints {
+		h := *hint
+		h.ID = ""
+		copied.Hints = append(copied.Hints, &h)
+	}
+	for _, _flag := range bundle.Flags {
+		f := *_flag
+		f.ID = ""
+		copied.Flags = append(copied.Flags, &f)
+	}
+	return &copied
+}
+
+//InvalidBundleError an Error due to a Bundle which cannot be Parsed or Saved, at the Path of the Bundle
+type InvalidBundleError struct {
+	Path string
+	Err  error
+}
+
+func (err *InvalidBundleError) Error() string {
+	return fmt.Sprintf("%s: %v", err.Path, err.Err)
+}
+
+//LoadBundles Load All the Bundles in the Directory Tree
+func LoadBundles(root string) ([]*Bundle, error) {
+	bundles := make([]*Bundle, 0)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != BundleFileName {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		bundle := &Bundle{}
+		if err := yaml.Unmarshal(data, bundle); err != nil {
+			rel, _ := filepath.Rel(root, path)
+			return &InvalidBundleError{Path: rel, Err: err}
+		}
+		bundle.dir = filepath.Dir(path)
+		bundle.normalize()
+		bundles = append(bundles, bundle)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bundles, nil
+}
+
+//diffBundles List the Fields Different between the Bundles, except the IDs and the Files
+func diffBundles(a *Bundle, b *Bundle) []string {
+	diffs := diffFields(a, b)
+	drift := make([]string, len(diffs))
+	for i, diff := range diffs {
+		drift[i] = diff.Field
+	}
+	return drift
+}
+
+//diffFields Get the Differences of the Fields between the Bundles, except the IDs and the Files
+func diffFields(a *Bundle, b *Bundle) []*FieldDiff {
+	a, b = a.withoutItemIDs(), b.withoutItemIDs()
+	diffs := make([]*FieldDiff, 0)
+	va, vb, t := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), reflect.TypeOf(a).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Name == "ID" || field.Name == "Files" {
+			continue
+		}
+		x, y := va.Field(i).Interface(), vb.Field(i).Interface()
+		if reflect.DeepEqual(x, y) {
+			continue
+		}
+		before, _ := yaml.Marshal(x)
+		after, _ := yaml.Marshal(y)
+		diffs = append(diffs, &FieldDiff{
+			Field:  strings.Split(field.Tag.Get("yaml"), ",")[0],
+			Before: strings.TrimSuffix(string(before), "\n"),
+			After:  strings.TrimSuffix(string(after), "\n"),
+		})
+	}
+	return diffs
+}
+
+//fileSHA256 Calculate the SHA-256 Checksum of the File
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+//filePath Get the Path of the File of the Bundle, Rejecting the Names Outside of the Bundle Directory
+func (bundle *Bundle) filePath(name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("invalid file: %s", name)
+	}
+	path := filepath.Join(bundle.dir, name)
+	if rel, err := filepath.Rel(bundle.dir, path); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid file: %s", name)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("invalid file: %s", name)
+	}
+	return path, nil
+}
+
+//diffFiles List the Files of the Bundle which are Missing or Different in the Attachments of the Challenge, and the Attachments Not in the Bundle
+func (bundle *Bundle) diffFiles(challenge *Challenge) ([]string, []*Attachment, error) {
+	attachments := make(map[string]*Attachment)
+	for _, attachment := range challenge.Attachments {
+		attachments[attachment.Name] = attachment
+	}
+	changed := make([]string, 0)
+	for _, name := range bundle.Files {
+		path, err := bundle.filePath(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if attachment, ok := attachments[filepath.Base(name)]; ok && attachment.SHA256 == sum {
+			delete(attachments, filepath.Base(name))
+			continue
+		}
+		changed = append(changed, name)
+	}
+	stale := make([]*Attachment, 0, len(attachments))
+	for _, attachment := range challenge.Attachments {
+		if _, ok := attachments[attachment.Name]; ok {
+			stale = append(stale, attachment)
+		}
+	}
+	return changed, stale, nil
+}
+
+//syncFiles Make the Attachments of the Challenge the Same as the Files of the Bundle
+func (bundle *Bundle) syncFiles(tx *gorm.DB, challenge *Challenge, files *storedFiles) error {
+	changed, stale, err := bundle.diffFiles(challenge)
+	if err != nil {
+		return err
+	}
+	for _, attachment := range stale {
+		if err := challenge.detachAttachment(tx, attachment); err != nil {
+			return err
+		}
+		files.removed = append(files.removed, attachment.ID)
+	}
+	for _, name := range changed {
+		for _, attachment := range challenge.Attachments {
+			if attachment.Name == filepath.Base(name) {
+				if err := challenge.detachAttachment(tx, attachment); err != nil {
+					return err
+				}
+				files.removed = append(files.removed, attachment.ID)
+				break
+			}
+		}
+		path, err := bundle.filePath(name)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		attachment, err := challenge.addAttachment(tx, name, contentType, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		files.added = append(files.added, attachment.ID)
+	}
+	return nil
+}
+
+//storedFiles the Keys of the Files Added and Removed in a Transaction, which are Deleted from the Storage after it Ends
+type storedFiles struct {
+	added   []string
+	removed []string
+}
+
+//cleanUp Delete the Files which are No Longer Attached, which are the Removed Ones if the Transaction has been Committed, or Else the Added Ones
+func (files *storedFiles) cleanUp(committed bool) {
+	keys := map[bool][]string{true: files.removed, false: files.added}[committed]
+	for _, key := range keys {
+		fileStorage.Delete(key)
+	}
+}
+
+//ErrAmbiguousAuthor an Error due to the Author Referred by the ID or the Name of More than One User
+var ErrAmbiguousAuthor = fmt.Errorf("more than one user matches the author; refer to the author by the ID")
+
+//findAuthor Find the Only User Referred by the ID or the Name
+func findAuthor(ref string) (*User, error) {
+	users := make([]*User, 0)
+	if err := db.Where("id = ? OR name = ?", ref, ref).Limit(2).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("author %q: %v", ref, err)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("author %q: %v", ref, ErrUserNotFound)
+	}
+	if len(users) > 1 {
+		return nil, fmt.Errorf("author %q: %v", ref, ErrAmbiguousAuthor)
+	}
+	return users[0], nil
+}
+
+//matchItemIDs Match the IDs of the Hints or the Flags in a Bundle with the Known Ones, Falling Back to the Live Ones in the Same Positions, and List the Live Ones Left Unmatched
+func matchItemIDs(requested []string, known map[string]struct{}, live []string) ([]string, []string) {
+	ids, used := make([]string, len(requested)), make(map[string]struct{})
+	for i, id := range requested {
+		if _, ok := known[id]; !ok {
+			continue
+		}
+		if _, ok := used[id]; !ok {
+			ids[i], used[id] = id, struct{}{}
+		}
+	}
+	for i := range requested {
+		if ids[i] != "" || i >= len(live) {
+			continue
+		}
+		if _, ok := used[live[i]]; !ok {
+			ids[i], used[live[i]] = live[i], struct{}{}
+		}
+	}
+	removed := make([]string, 0)
+	for _, id := range live {
+		if _, ok := used[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	return ids, removed
+}
+
+//edits Make the Edits to Turn the Hints and the Flags of the Challenge into the Ones of the Bundle
+func (bundle *Bundle) edits(challenge *Challenge) ([]*HintEdit, []*FlagEdit, error) {
+	knownHints, knownFlags := make(map[string]struct{}), make(map[string]struct{})
+	liveHints, liveFlags := make([]string, 0), make([]string, 0)
+	if challenge != nil {
+		hints, flags, err := loadItems(db, challenge.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for id := range hints {
+			knownHints[id] = struct{}{}
+		}
+		for id := range flags {
+			knownFlags[id] = struct{}{}
+		}
+		current := newBundle(challenge, nil)
+		for _, hint := range current.Hints {
+			liveHints = append(liveHints, hint.ID)
+		}
+		for _, _flag := range current.Flags {
+			liveFlags = append(liveFlags, _flag.ID)
+		}
+	}
+
+	requested := make([]string, len(bundle.Hints))
+	for i, hint := range bundle.Hints {
+		requested[i] = hint.ID
+	}
+	ids, removed := matchItemIDs(requested, knownHints, liveHints)
+	hintEdits := make([]*HintEdit, 0, len(bundle.Hints)+len(removed))
+	for i, hint := range bundle.Hints {
+		hintEdits = append(hintEdits, &HintEdit{
+			ID:             ids[i],
+			Caption:        hint.Caption,
+			PenaltyPercent: hint.Penalty,
+			Cost:           hint.Cost,
+			ReleaseAt:      hint.ReleaseAt,
+		})
+	}
+	for _, id := range removed {
+		hintEdits = append(hintEdits, &HintEdit{ID: id, Remove: true})
+	}
+
+	requested = make([]string, len(bundle.Flags))
+	for i, _flag := range bundle.Flags {
+		requested[i] = _flag.ID
+	}
+	ids, removed = matchItemIDs(requested, knownFlags, liveFlags)
+	flagEdits := make([]*FlagEdit, 0, len(bundle.Flags)+len(removed))
+	for i, _flag := range bundle.Flags {
+		flagEdits = append(flagEdits, &FlagEdit{
+			ID:        ids[i],
+			Flag:      _flag.Flag,
+			MatchMode: _flag.MatchMode,
+			Secret:    _flag.Secret,
+			Score:     _flag.Score,
+		})
+	}
+	for _, id := range removed {
+		flagEdits = append(flagEdits, &FlagEdit{ID: id, Remove: true})
+	}
+	return hintEdits, flagEdits, nil
+}
+
+//validate Check that the Bundle can be Saved, Referring Only to the Challenges with the Keys, and that its Files can be Read, without Writing Anything.
+//The Genres of the Required Challenges Referred by their Names Only are Filled
+func (bundle *Bundle) validate(ids map[challengeKey]string) error {
+	if _, err := findAuthor(bundle.authorRef()); err != nil {
+		return err
+	}
+	if err := validateScoring(bundle.ScoringMode, bundle.Score, bundle.MinimumScore, bundle.Decay); err != nil {
+		return err
+	}
+	if err := validateBonuses(bundle.Bonuses); err != nil {
+		return err
+	}
+	hintEdits := make([]*HintEdit, len(bundle.Hints))
+	for i, hint := range bundle.Hints {
+		hintEdits[i] = &HintEdit{PenaltyPercent: hint.Penalty, Cost: hint.Cost, ReleaseAt: hint.ReleaseAt}
+	}
+	if err := validateHintEdits(hintEdits); err != nil {
+		return err
+	}
+	flagEdits := make([]*FlagEdit, len(bundle.Flags))
+	for i, _flag := range bundle.Flags {
+		flagEdits[i] = &FlagEdit{Flag: _flag.Flag, MatchMode: _flag.MatchMode, Secret: _flag.Secret, Score: _flag.Score}
+	}
+	if err := validateFlagEdits(bundle.FlagFormat, flagEdits); err != nil {
+		return err
+	}
+	if err := validateSchedule(bundle.ReleaseAt, bundle.HideAt); err != nil {
+		return err
+	}
+	for _, prerequisite := range bundle.Prerequisites {
+		if prerequisite.Challenge == "" || prerequisite.ChallengeID != "" {
+			continue
+		}
+		id, err := prerequisite.requiredID(ids)
+		if err != nil {
+			return err
+		}
+		for key, other := range ids {
+			if prerequisite.ChallengeGenre == "" && key.Genre != "" && key.Name == prerequisite.Challenge && other == id {
+				prerequisite.ChallengeGenre = key.Genre
+			}
+		}
+	}
+	for _, name := range bundle.Files {
+		if _, err := bundle.filePath(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//impacts List the Effects of Updating the Challenge as the Bundle on the Users who have Already Opened the Hints or Found the Flags
+func (bundle *Bundle) impacts(challenge *Challenge) ([]*EditImpact, error) {
+	hintEdits, flagEdits, err := bundle.edits(challenge)
+	if err != nil {
+		return nil, err
+	}
+	return challenge.EditImpacts(bundle.Score, hintEdits, flagEdits)
+}
+
+//authorRef Get the Reference to the Author, Preferring the ID in a Snapshot to the Name, which may have been Changed since
+func (bundle *Bundle) authorRef() string {
+	if bundle.AuthorID != "" {
+		return bundle.AuthorID
+	}
+	return bundle.Author
+}
+
+//save Create or Update the Challenge as the Bundle in the Transaction, Resolving the Required Challenges by the Keys
+func (bundle *Bundle) save(tx *gorm.DB, challenge *Challenge, ids map[challengeKey]string, editorID string) (*Challenge, error) {
+	author, err := findAuthor(bundle.authorRef())
+	if err != nil {
+		return nil, err
+	}
+	hintEdits, flagEdits, err := bundle.edits(challenge)
+	if err != nil {
+		return nil, err
+	}
+	prerequisites := make([]*Prerequisite, 0, len(bundle.Prerequisites))
+	for _, prerequisite := range bundle.Prerequisites {
+		requiredID := prerequisite.ChallengeID
+		if requiredID == "" && prerequisite.Challenge != "" {
+			id, err := prerequisite.requiredID(ids)
+			if err != nil {
+				if challenge == nil {
+					continue
+				}
+				return nil, err
+			}
+			requiredID = id
+		}
+		prerequisites = append(prerequisites, &Prerequisite{
+			RequiredChallengeID: requiredID,
+			Genre:               prerequisite.Genre,
+			GenreScore:          prerequisite.Score,
+		})
+	}
+
+	if challenge == nil {
+		return newChallenge(tx, bundle.Genre, bundle.Name, author.ID, bundle.Score, bundle.ScoringMode, bundle.MinimumScore, bundle.Decay, bundle.Bonuses, bundle.Caption, hintEdits, flagEdits, bundle.FlagFormat, bundle.Answer, prerequisites, bundle.ReleaseAt, bundle.HideAt)
+	}
+	return challenge, challenge.update(tx, bundle.Genre, bundle.Name, author.ID, bundle.Score, bundle.ScoringMode, bundle.MinimumScore, bundle.Decay, bundle.Bonuses, bundle.Caption, hintEdits, flagEdits, bundle.FlagFormat, bundle.Answer, prerequisites, bundle.ReleaseAt, bundle.HideAt, editorID)
+}
+
+//ImportBundles Create or Update the Challenges as the Bundles in the Directory Tree by the Editor, or Only Report the Drift if dryRun is true.
+//Every Bundle is Validated before Anything is Written, and All the Challenges are Saved in a Transaction, so an Import which Failed on the Way Writes Nothing.
+//Nothing is Written unless confirm is true if the Updates Affect the Users who have Already Opened the Hints or Found the Flags
+func ImportBundles(root string, dryRun bool, confirm bool, editorID string) (*ImportReport, error) {
+	bundles, err := LoadBundles(root)
+	if err != nil {
+		return nil, err
+	}
+	challenges, err := GetChallenges()
+	if err != nil {
+		return nil, err
+	}
+
+	byID, byKey, ids := make(map[string]*Challenge), make(map[challengeKey]*Challenge), make(map[challengeKey]string)
+	for _, challenge := range challenges {
+		byID[challenge.ID] = challenge
+		byKey[challengeKey{Genre: challenge.Genre, Name: challenge.Name}] = challenge
+		addChallengeKey(ids, challenge.Genre, challenge.Name, challenge.ID)
+	}
+	matched := make([]*Challenge, len(bundles))
+	known := make(map[challengeKey]string, len(ids))
+	for key, id := range ids {
+		known[key] = id
+	}
+	for i, bundle := range bundles {
+		challenge, ok := byID[bundle.ID]
+		if !ok {
+			challenge = byKey[challengeKey{Genre: bundle.Genre, Name: bundle.Name}]
+		}
+		//The Challenges to be Created are Known by Placeholders, which are Never Saved
+		id := "bundle:" + bundle.dir
+		if challenge != nil {
+			id = challenge.ID
+			delete(known, challengeKey{Genre: challenge.Genre, Name: challenge.Name})
+		}
+		matched[i] = challenge
+		addChallengeKey(known, bundle.Genre, bundle.Name, id)
+	}
+	for _, bundle := range bundles {
+		if err := bundle.validate(known); err != nil {
+			path, _ := filepath.Rel(root, bundle.dir)
+			return nil, &InvalidBundleError{Path: path, Err: err}
+		}
+	}
+
+	report := &ImportReport{
+		DryRun:  dryRun,
+		Results: make([]*BundleResult, len(bundles)),
+	}
+	unconfirmed := false
+	for i, bundle := range bundles {
+		path, _ := filepath.Rel(root, bundle.dir)
+		result := &BundleResult{
+			Path:   path,
+			Genre:  bundle.Genre,
+			Name:   bundle.Name,
+			Action: BundleCreate,
+		}
+		challenge := matched[i]
+		if challenge != nil {
+			result.ID = challenge.ID
+			current := newBundle(challenge, byID)
+			if bundle.Author == challenge.AuthorID {
+				current.Author = bundle.Author
+			}
+			result.Drift = diffBundles(current, bundle)
+			changed, stale, err := bundle.diffFiles(challenge)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			if len(changed) > 0 || len(stale) > 0 {
+				result.Drift = append(result.Drift, "files")
+			}
+			result.Action = map[bool]string{true: BundleUnchanged, false: BundleUpdate}[len(result.Drift) == 0]
+			if result.Action == BundleUpdate {
+				impacts, err := bundle.impacts(challenge)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", path, err)
+				}
+				result.Impacts = impacts
+				unconfirmed = unconfirmed || len(impacts) > 0
+			}
+		}
+		report.Results[i] = result
+	}
+	if dryRun {
+		return report, nil
+	}
+	if unconfirmed && !confirm {
+		return report, ErrUnconfirmedEdit
+	}
+
+	tx, files := db.Begin(), &storedFiles{}
+	if err := saveBundles(tx, bundles, report, matched, ids, files, editorID); err != nil {
+		tx.Rollback()
+		files.cleanUp(false)
+		return report, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		files.cleanUp(false)
+		return report, err
+	}
+	files.cleanUp(true)
+	invalidateScoreCaches()
+	return report, nil
+}
+
+//saveBundles Create the New Challenges as the Bundles in the Transaction First, so that they can be Required, and then Update the Others
+func saveBundles(tx *gorm.DB, bundles []*Bundle, report *ImportReport, matched []*Challenge, ids map[challengeKey]string, files *storedFiles, editorID string) error {
+	for i, bundle := range bundles {
+		result := report.Results[i]
+		if result.Action != BundleCreate {
+			continue
+		}
+		challenge, err := bundle.save(tx, nil, ids, editorID)
+		if err != nil {
+			return fmt.Errorf("%s: %v", result.Path, err)
+		}
+		result.ID, matched[i] = challenge.ID, challenge
+		addChallengeKey(ids, challenge.Genre, challenge.Name, challenge.ID)
+	}
+	for i, bundle := range bundles {
+		result := report.Results[i]
+		if result.Action == BundleUnchanged {
+			continue
+		}
+		if result.Action == BundleUpdate || len(bundle.Prerequisites) > 0 {
+			if _, err := bundle.save(tx, matched[i], ids, editorID); err != nil {
+				return fmt.Errorf("%s: %v", result.Path, err)
+			}
+		}
+		if err := bundle.syncFiles(tx, matched[i], files); err != nil {
+			return fmt.Errorf("%s: %v", result.Path, err)
+		}
+	}
+	return nil
+}
+
+//bundleDirName Make a Name of a Directory from the Name of a Genre or a Challenge, which Never Refers to the Directory Itself or its Parent
+func bundleDirName(name string) string {
+	if strings.Trim(name, ".") == "" {
+		return strings.Repeat("_", len(name)+1)
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+//uniqueDirName Make the Name of a Directory Unique among the Used Ones, Ignoring the Case for Case-Insensitive File Systems, by Numbering it
+func uniqueDirName(name string, used map[string]struct{}) string {
+	unique := name
+	for i := 2; ; i++ {
+		if _, ok := used[strings.ToLower(unique)]; !ok {
+			break
+		}
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	used[strings.ToLower(unique)] = struct{}{}
+	return unique
+}
+
+//ExportBundles Write All the Challenges as Bundles into the Directory, and Return How Many Challenges are Exported
+func ExportBundles(root string) (int, error) {
+	challenges, err := GetChallenges()
+	if err != nil {
+		return 0, err
+	}
+	byID := make(map[string]*Challenge)
+	for _, challenge := range challenges {
+		byID[challenge.ID] = challenge
+	}
+
+	//Genres and Challenges whose Names are Sanitized into the Same Ones are Written into Separate Directories
+	genreDirs, usedGenreDirs := make(map[string]string), make(map[string]struct{})
+	usedDirs := make(map[string]map[string]struct{})
+	for _, challenge := range challenges {
+		genreDir, ok := genreDirs[challenge.Genre]
+		if !ok {
+			genreDir = uniqueDirName(bundleDirName(challenge.Genre), usedGenreDirs)
+			genreDirs[challenge.Genre], usedDirs[genreDir] = genreDir, make(map[string]struct{})
+		}
+		dir := filepath.Join(root, genreDir, uniqueDirName(bundleDirName(challenge.Name), usedDirs[genreDir]))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, err
+		}
+		data, err := yaml.Marshal(newBundle(challenge, byID).portable())
+		if err != nil {
+			return 0, err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, BundleFileName), data, 0644); err != nil {
+			return 0, err
+		}
+		for _, attachment := range challenge.Attachments {
+			if err := exportAttachment(attachment, filepath.Join(dir, attachment.Name)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(challenges), nil
+}
+
+func exportAttachment(attachment *Attachment, path string) error {
+	content, err := attachment.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -1,7 +1,6 @@
 package model
 
 import (
-	"strconv"
 	"time"
 
 	"github.com/jinzhu/gorm"
@@ -16,11 +15,21 @@ type Challenge struct {
 	AuthorID      string
 	Author        *User `gorm:"foreignkey:AuthorID"`
 	Score         int
+	ScoringMode   string
+	MinimumScore  int
+	Decay         int
+	Bonuses       string
 	Caption       string `sql:"type:varchar(1500);"`
 	Hints         []*Hint
 	Flags         []*Flag
+	Attachments   []*Attachment
+	FlagFormat    string
 	Answer        string
+	Prerequisites []*Prerequisite
+	ReleaseAt     *time.Time
+	HideAt        *time.Time
 	WhoSolved     []*User `gorm:"many2many:user_solved_challenges;"`
+	Solves        []*Solve
 	WhoChallenged []*User `gorm:"many2many:user_challenged_challenges;"`
 	Votes         []*Vote
 	CreatedAt     time.Time
@@ -33,7 +42,10 @@ type Flag struct {
 	ID          string `gorm:"primary_key"`
 	ChallengeID string
 	Flag        string
+	MatchMode   string
+	Secret      string
 	Score       int
+	Position    int
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	DeletedAt   *time.Time
@@ -45,11 +57,26 @@ type Hint struct {
 	ChallengeID    string
 	Caption        string `sql:"type:varchar(3000);"`
 	PenaltyPercent int
+	Cost           int
+	ReleaseAt      *time.Time
+	Position       int
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	DeletedAt      *time.Time
 }
 
+//Solve a Record of the User's Solving the Challenge
+type Solve struct {
+	UserID      string    `gorm:"primary_key"`
+	ChallengeID string    `gorm:"primary_key"`
+	CreatedAt   time.Time `sql:"DEFAULT:CURRENT_TIMESTAMP"`
+}
+
+//TableName the Name of the Table of Solve Records
+func (Solve) TableName() string {
+	return "user_solved_challenges"
+}
+
 //Vote a Vote Record
 type Vote struct {
 	gorm.Model
@@ -61,10 +88,18 @@ type Vote struct {
 //ErrChallengeNotFound an Error due to the Challenge Not Found
 var ErrChallengeNotFound = gorm.ErrRecordNotFound
 
+func orderSolves(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at").Order("user_id")
+}
+
+func orderAttachments(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at")
+}
+
 //GetChallenges Get All Challenge Records
 func GetChallenges() ([]*Challenge, error) {
 	challenges := make([]*Challenge, 0)
-	if err := db.Preload("Author").Preload("Hints").Preload("Flags").Preload("WhoSolved").Preload("WhoChallenged").Preload("Votes").Order("genre").Order("name").Find(&challenges).Error; err != nil {
+	if err := db.Preload("Author").Preload("Hints", orderPositions).Preload("Flags", orderPositions).Preload("Prerequisites").Preload("Attachments", orderAttachments).Preload("WhoSolved").Preload("Solves", orderSolves).Preload("WhoChallenged").Preload("Votes").Order("genre").Order("name").Find(&challenges).Error; err != nil {
 		return nil, err
 	}
 	return challenges, nil
@@ -73,192 +108,363 @@ func GetChallenges() ([]*Challenge, error) {
 //GetChallengeByID Get the Challenge Record by its ID
 func GetChallengeByID(id string) (*Challenge, error) {
 	challenge := &Challenge{}
-	if err := db.Where(&Challenge{ID: id}).Preload("Author").Preload("Hints").Preload("Flags").Preload("WhoSolved").Preload("WhoChallenged").Preload("Votes").First(challenge).Error; err != nil {
+	if err := db.Where(&Challenge{ID: id}).Preload("Author").Preload("Hints", orderPositions).Preload("Flags", orderPositions).Preload("Prerequisites").Preload("Attachments", orderAttachments).Preload("WhoSolved").Preload("Solves", orderSolves).Preload("WhoChallenged").Preload("Votes").First(challenge).Error; err != nil {
 		return nil, err
 	}
 	return challenge, nil
 }
 
 //NewChallenge Make a New Challenge Record
-func NewChallenge(genre string, name string, authorID string, score int, caption string, captions []string, penalties []int, flags []string, scores []int, answer string) (*Challenge, error) {
-	id := uuid.NewV4().String()
-	hints := make([]*Hint, len(captions))
-	for i := 0; i < len(captions); i++ {
-		hints[i] = &Hint{
-			ID:             id + ":" + strconv.Itoa(i),
-			Caption:        captions[i],
-			PenaltyPercent: penalties[i],
-		}
+func NewChallenge(genre string, name string, authorID string, score int, scoringMode string, minimumScore int, decay int, bonuses []int, caption string, hintEdits []*HintEdit, flagEdits []*FlagEdit, flagFormat string, answer string, prerequisites []*Prerequisite, releaseAt *time.Time, hideAt *time.Time) (*Challenge, error) {
+	tx := db.Begin()
+	challenge, err := newChallenge(tx, genre, name, authorID, score, scoringMode, minimumScore, decay, bonuses, caption, hintEdits, flagEdits, flagFormat, answer, prerequisites, releaseAt, hideAt)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
-	_flags := make([]*Flag, len(flags))
-	for i := 0; i < len(flags); i++ {
-		_flags[i] = &Flag{
-			ID:    id + ":" + strconv.Itoa(i),
-			Flag:  flags[i],
-			Score: scores[i],
-		}
+	return challenge, tx.Commit().Error
+}
+
+//newChallenge Create a Challenge Record in the Transaction
+func newChallenge(tx *gorm.DB, genre string, name string, authorID string, score int, scoringMode string, minimumScore int, decay int, bonuses []int, caption string, hintEdits []*HintEdit, flagEdits []*FlagEdit, flagFormat string, answer string, prerequisites []*Prerequisite, releaseAt *time.Time, hideAt *time.Time) (*Challenge, error) {
+	if err := validateScoring(scoringMode, score, minimumScore, decay); err != nil {
+		return nil, err
+	}
+	if err := validateBonuses(bonuses); err != nil {
+		return nil, err
+	}
+	if err := validateHintEdits(hintEdits); err != nil {
+		return nil, err
+	}
+	if err := validateFlagEdits(flagFormat, flagEdits); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(releaseAt, hideAt); err != nil {
+		return nil, err
 	}
 
-	tx := db.Begin()
+	id := uuid.NewV4().String()
 
 	author := &User{}
 	if err := tx.Where(&User{ID: authorID}).First(author).Error; err != nil {
-		tx.Rollback()
+		return nil, err
+	}
+
+	if err := validatePrerequisites(tx, id, prerequisites); err != nil {
 		return nil, err
 	}
 
 	challenge := &Challenge{
-		ID:      id,
-		Genre:   genre,
-		Name:    name,
-		Author:  author,
-		Score:   score,
-		Caption: caption,
-		Hints:   hints,
-		Flags:   _flags,
-		Answer:  answer,
+		ID:           id,
+		Genre:        genre,
+		Name:         name,
+		Author:       author,
+		Score:        score,
+		ScoringMode:  map[bool]string{true: ScoringStatic, false: scoringMode}[scoringMode == ""],
+		MinimumScore: minimumScore,
+		Decay:        decay,
+		Bonuses:      joinBonuses(bonuses),
+		Caption:      caption,
+		FlagFormat:   flagFormat,
+		Answer:       answer,
+		ReleaseAt:    releaseAt,
+		HideAt:       hideAt,
 	}
 	if err := tx.Set("gorm:save_associations", true).Create(challenge).Error; err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
-	return challenge, tx.Commit().Error
-}
+	if err := applyEdits(tx, challenge, score, hintEdits, flagEdits); err != nil {
+		return nil, err
+	}
+
+	if err := replacePrerequisites(tx, id, prerequisites); err != nil {
+		return nil, err
+	}
+	challenge.Prerequisites = prerequisites
 
-//Delete Delete the Challenge Record
-func (challenge *Challenge) Delete() error {
-	return db.Delete(challenge).Error
+	return challenge, nil
 }
 
-//Update Update the Challenge Record
-func (challenge *Challenge) Update(genre string, name string, authorID string, score int, caption string, captions []string, penalties []int, flags []string, scores []int, answer string) error {
-	hints := make([]*Hint, len(captions))
-	for i := 0; i < len(captions); i++ {
-		hints[i] = &Hint{
-			ID:             challenge.ID + ":" + strconv.Itoa(i),
-			Caption:        captions[i],
-			PenaltyPercent: penalties[i],
-		}
+//Delete Move the Challenge Record to the Trash, Keeping the Points Awarded for it
+func (challenge *Challenge) Delete(actorID string) error {
+	tx := db.Begin()
+
+	if err := tx.Delete(challenge).Error; err != nil {
+		tx.Rollback()
+		return err
 	}
-	_flags := make([]*Flag, len(flags))
-	for i := 0; i < len(flags); i++ {
-		_flags[i] = &Flag{
-			ID:    challenge.ID + ":" + strconv.Itoa(i),
-			Flag:  flags[i],
-			Score: scores[i],
-		}
+
+	if err := recordAudit(tx, actorID, AuditTrashChallenge, challenge.ID, challenge.Name); err != nil {
+		tx.Rollback()
+		return err
 	}
 
+	return tx.Commit().Error
+}
+
+//Update Update the Challenge Record, Recording the Previous State as a Revision by the Editor
+func (challenge *Challenge) Update(genre string, name string, authorID string, score int, scoringMode string, minimumScore int, decay int, bonuses []int, caption string, hintEdits []*HintEdit, flagEdits []*FlagEdit, flagFormat string, answer string, prerequisites []*Prerequisite, releaseAt *time.Time, hideAt *time.Time, editorID string) error {
 	tx := db.Begin()
+	if err := challenge.update(tx, genre, name, authorID, score, scoringMode, minimumScore, decay, bonuses, caption, hintEdits, flagEdits, flagFormat, answer, prerequisites, releaseAt, hideAt, editorID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	invalidateScoreCaches()
+	return nil
+}
+
+//update Update the Challenge Record in the Transaction, Recording the Previous State as a Revision by the Editor, without Invalidating the Caches of the Scores
+func (challenge *Challenge) update(tx *gorm.DB, genre string, name string, authorID string, score int, scoringMode string, minimumScore int, decay int, bonuses []int, caption string, hintEdits []*HintEdit, flagEdits []*FlagEdit, flagFormat string, answer string, prerequisites []*Prerequisite, releaseAt *time.Time, hideAt *time.Time, editorID string) error {
+	if err := validateScoring(scoringMode, score, minimumScore, decay); err != nil {
+		return err
+	}
+	if err := validateBonuses(bonuses); err != nil {
+		return err
+	}
+	if err := validateHintEdits(hintEdits); err != nil {
+		return err
+	}
+	if err := validateFlagEdits(flagFormat, flagEdits); err != nil {
+		return err
+	}
+	if err := validateSchedule(releaseAt, hideAt); err != nil {
+		return err
+	}
 
 	author := &User{}
 	if err := tx.Where(&User{ID: authorID}).First(author).Error; err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	challenge.Genre, challenge.Name, challenge.Author, challenge.Score, challenge.Caption, challenge.Hints, challenge.Flags, challenge.Answer = genre, name, author, score, caption, hints, _flags, answer
+	if err := validatePrerequisites(tx, challenge.ID, prerequisites); err != nil {
+		return err
+	}
+
+	before, err := snapshotChallenge(tx, challenge)
+	if err != nil {
+		return err
+	}
+
+	previousScore := challenge.Score
+	challenge.Genre, challenge.Name, challenge.Author, challenge.Score, challenge.Caption, challenge.Hints, challenge.Flags, challenge.Answer = genre, name, author, score, caption, nil, nil, answer
+	challenge.FlagFormat, challenge.ReleaseAt, challenge.HideAt = flagFormat, releaseAt, hideAt
+	challenge.ScoringMode, challenge.MinimumScore, challenge.Decay, challenge.Bonuses = map[bool]string{true: ScoringStatic, false: scoringMode}[scoringMode == ""], minimumScore, decay, joinBonuses(bonuses)
 	if err := tx.Set("gorm:save_associations", true).Save(challenge).Error; err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	return tx.Commit().Error
-}
+	if err := replacePrerequisites(tx, challenge.ID, prerequisites); err != nil {
+		return err
+	}
+	challenge.Prerequisites = prerequisites
+
+	if err := applyEdits(tx, challenge, previousScore, hintEdits, flagEdits); err != nil {
+		return err
+	}
+	if err := rebuildSolves(tx, challenge); err != nil {
+		return err
+	}
+	if err := rebuildPenalties(tx, challenge.ID); err != nil {
+		return err
+	}
 
-//CheckAnswer Check the Answer
-func (challenge *Challenge) CheckAnswer(user *User, flag string) (bool, int, error) {
+	if err := recordRevision(tx, challenge, before, editorID); err != nil {
+		return err
+	}
 
+	return recalcScoresOfChallenge(tx, challenge.ID)
+}
+
+//CheckAnswer Check the Answer and Record it as a Submission
+func (challenge *Challenge) CheckAnswer(user *User, flag string, ip string, userAgent string) (*Submission, error) {
 	now, finish := time.Now(), FinishTime()
+	if !user.IsAuthor && finish.After(now) {
+		progress, err := GetProgress(user)
+		if err != nil {
+			return nil, err
+		}
+		if !challenge.IsUnlocked(progress) {
+			return nil, ErrChallengeLocked
+		}
+	}
+
 	tx := db.Begin()
 
-	hints := make([]*Hint, 0)
-	if err := tx.Where(&Hint{ChallengeID: challenge.ID}).Model(user).Association("OpenedHints").Find(&hints).Error; err != nil {
+	userIDs, err := teammateIDs(tx, user)
+	if err != nil {
 		tx.Rollback()
-		return false, 0, err
+		return nil, err
 	}
-	_flags := make([]*FoundFlag, 0)
-	if err := tx.Where(&FoundFlag{ChallengeID: challenge.ID}).Model(user).Association("FoundFlags").Find(&_flags).Error; err != nil {
+	hints := make([]*Hint, 0)
+	if err := tx.Joins("JOIN user_opened_hints ON user_opened_hints.hint_id = hints.id").Where("user_opened_hints.user_id IN (?)", userIDs).Where(&Hint{ChallengeID: challenge.ID}).Select("DISTINCT hints.*").Find(&hints).Error; err != nil {
 		tx.Rollback()
-		return false, 0, err
+		return nil, err
 	}
 
-	isCorrect := true
-	_flag := &Flag{}
-	err := tx.Where(&Flag{ChallengeID: challenge.ID, Flag: flag}).First(_flag).Error
-	if err == gorm.ErrRecordNotFound {
-		isCorrect = false
-	} else if err != nil {
+	flags := make([]*Flag, 0)
+	if err := tx.Where(&Flag{ChallengeID: challenge.ID}).Find(&flags).Error; err != nil {
 		tx.Rollback()
-		return false, 0, err
-	}
-	if len(flag) < 5 {
-		isCorrect = false
+		return nil, err
 	}
 
-	score := 0
-	scoreDelta := 0
-	if isCorrect {
-
-		penaltySum := 0
-		for _, hint := range hints {
-			penaltySum += hint.PenaltyPercent
-		}
-		score = _flag.Score * (100 - penaltySum) / 100
-
-		nowScore := 0
-		for _, f := range _flags {
-			if nowScore < f.Score {
-				nowScore = f.Score
+	isCorrect := false
+	_flag := &Flag{}
+	if challenge.MatchesFlagFormat(flag) {
+		for _, f := range flags {
+			if f.MatchAny(flag, userIDs) && (!isCorrect || _flag.Score < f.Score) {
+				isCorrect, _flag = true, f
 			}
 		}
-
-		if nowScore < score {
-			scoreDelta = score - nowScore
-		}
 	}
-	if finish.After(now) {
-		user.Score += scoreDelta
 
+	scoreDelta, duplicate := 0, false
+	if finish.After(now) {
 		if err := tx.Model(challenge).Association("WhoChallenged").Append(user).Error; err != nil {
 			tx.Rollback()
-			return false, 0, err
+			return nil, err
 		}
 
-		newFlag := &FoundFlag{
-			FlagID:      _flag.ID,
-			ChallengeID: _flag.ChallengeID,
-			Score:       score,
+		isSolved := isCorrect && _flag.Score == challenge.Score
+		if isSolved && len(userIDs) > 1 {
+			//Teammates Submitting at the Same Time Wait for Each Other on the Team Record, and Only the First of them Solves it
+			if err := tx.Set("gorm:query_option", "FOR UPDATE").Where(&Team{ID: user.TeamID}).First(&Team{}).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			solves := make([]*Solve, 0)
+			if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("user_id IN (?) AND challenge_id = ?", userIDs, challenge.ID).Find(&solves).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			duplicate = len(solves) > 0
 		}
-		if isCorrect {
-			if err := db.Create(newFlag).Error; err != nil {
-				return false, 0, err
+		if isSolved && !duplicate {
+			//A Concurrent Submission of the Same User may have Solved it, in which case this One Counts for Nothing
+			result := tx.Exec("INSERT IGNORE INTO user_solved_challenges (user_id, challenge_id, created_at) VALUES (?, ?, ?)", user.ID, challenge.ID, now)
+			if result.Error != nil {
+				tx.Rollback()
+				return nil, result.Error
+			}
+			duplicate = result.RowsAffected == 0
+		}
+
+		if isCorrect && !duplicate {
+			penaltySum := PenaltyPercentOf(hints)
+
+			solves := len(challenge.WhoSolved)
+			if isSolved {
+				solves++
+			}
+
+			newFlag := &FoundFlag{
+				FlagID:         _flag.ID,
+				ChallengeID:    _flag.ChallengeID,
+				Score:          ApplyPenalty(challenge.FlagScoreAt(_flag, solves), penaltySum),
+				PenaltyPercent: penaltySum,
+			}
+			if err := tx.Create(newFlag).Error; err != nil {
+				tx.Rollback()
+				return nil, err
 			}
 			if err := tx.Model(user).Association("FoundFlags").Append(newFlag).Error; err != nil {
 				tx.Rollback()
-				return false, 0, err
+				return nil, err
 			}
 			user.FoundFlags = append(user.FoundFlags, newFlag)
 
-			if _flag.Score == challenge.Score {
-				if err := tx.Model(challenge).Association("WhoSolved").Append(user).Error; err != nil {
-					tx.Rollback()
-					return false, 0, err
-				}
+			if isSolved {
+				challenge.WhoSolved = append(challenge.WhoSolved, user)
+				challenge.Solves = append(challenge.Solves, &Solve{
+					UserID:      user.ID,
+					ChallengeID: challenge.ID,
+					CreatedAt:   now,
+				})
 			}
 
 			user.LastSolvedChallenge = challenge
-			user.LastSolvedTime = time.Now()
+			user.LastSolvedTime = now
 		}
 
 		if err := tx.Save(user).Error; err != nil {
 			tx.Rollback()
-			return false, 0, err
+			return nil, err
 		}
+
+		if isCorrect && !duplicate {
+			if challenge.IsDynamic() {
+				if err := recalcScoresOfChallenge(tx, challenge.ID); err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+			}
+			score, err := calcUserScore(tx, user.ID)
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			scoreDelta = score - user.Score
+			user.Score = score
+			if err := tx.Model(user).UpdateColumn("score", score).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if user.TeamID != "" {
+				if err := recalcTeamScore(tx, user.TeamID); err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+			}
+		}
+	}
+
+	submission := &Submission{
+		UserID:      user.ID,
+		ChallengeID: challenge.ID,
+		Flag:        flag,
+		IsCorrect:   isCorrect,
+		FlagID:      map[bool]string{true: _flag.ID}[isCorrect],
+		Score:       scoreDelta,
+		IP:          ip,
+		UserAgent:   userAgent,
+	}
+	if err := tx.Create(submission).Error; err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
-	return isCorrect, scoreDelta, tx.Commit().Error
+	//Authors, who are Not Rate Limited, are Not Checked for Sharing the Flags, so that the Flags of All the Users are Looked up Only for Limited Submissions
+	if !isCorrect && !user.IsAuthor {
+		ownerID, sharedFlag, err := findFlagOwner(tx, flags, flag, userIDs)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if sharedFlag != nil {
+			incident := &SharingIncident{
+				SubmitterID:  user.ID,
+				OwnerID:      ownerID,
+				ChallengeID:  challenge.ID,
+				FlagID:       sharedFlag.ID,
+				SubmissionID: submission.ID,
+			}
+			if err := tx.Create(incident).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	if isCorrect {
+		invalidateScoreCaches()
+	}
+	return submission, nil
 }
 
 //GetVote Get the User's Vote for the Challenge
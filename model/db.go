@@ -2,6 +2,8 @@ package model
 
 import (
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql" // mysql driver
@@ -16,10 +18,40 @@ func InitDB() error {
 	if err != nil {
 		return err
 	}
-	if err := db.AutoMigrate(&Challenge{}, &Hint{}, &Flag{}, &Vote{}, &Question{}, &User{}, &FoundFlag{}).Error; err != nil {
+	if err := db.AutoMigrate(&Challenge{}, &Hint{}, &Flag{}, &Vote{}, &Question{}, &QuestionMessage{}, &User{}, &FoundFlag{}, &Solve{}, &HintOpen{}, &ChallengeOpen{}, &Submission{}, &SharingIncident{}, &Team{}, &Setting{}, &Prerequisite{}, &Attachment{}, &Revision{}, &AuditLog{}, &Announcement{}, &Award{}).Error; err != nil {
 		return err
 	}
 	db = db.Set("gorm:save_associations", false)
+	if err := migratePositions(); err != nil {
+		return err
+	}
+	return migrateQuestions()
+}
+
+//migratePositions Set the Positions of the Hints and the Flags whose IDs are in the Old Form of "<challenge id>:<index>"
+func migratePositions() error {
+	hints := make([]*Hint, 0)
+	if err := db.Unscoped().Where("position = 0").Find(&hints).Error; err != nil {
+		return err
+	}
+	for _, hint := range hints {
+		if i, err := strconv.Atoi(hint.ID[strings.LastIndex(hint.ID, ":")+1:]); err == nil && i > 0 {
+			if err := db.Unscoped().Model(hint).UpdateColumn("position", i).Error; err != nil {
+				return err
+			}
+		}
+	}
+	flags := make([]*Flag, 0)
+	if err := db.Unscoped().Where("position = 0").Find(&flags).Error; err != nil {
+		return err
+	}
+	for _, _flag := range flags {
+		if i, err := strconv.Atoi(_flag.ID[strings.LastIndex(_flag.ID, ":")+1:]); err == nil && i > 0 {
+			if err := db.Unscoped().Model(_flag).UpdateColumn("position", i).Error; err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,447 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/satori/go.uuid"
+)
+
+//HintEdit an Edit of a Hint, which Adds a New Hint if ID is Empty, Removes the Hint if Remove is true, or Updates it Otherwise
+type HintEdit struct {
+	ID             string
+	Caption        string
+	PenaltyPercent int
+	Cost           int
+	ReleaseAt      *time.Time
+	Remove         bool
+}
+
+//FlagEdit an Edit of a Flag, which Adds a New Flag if ID is Empty, Removes the Flag if Remove is true, or Updates it Otherwise
+type FlagEdit struct {
+	ID        string
+	Flag      string
+	MatchMode string
+	Secret    string
+	Score     int
+	Remove    bool
+}
+
+//Kinds and Actions of EditImpacts
+const (
+	EditHint   = "hint"
+	EditFlag   = "flag"
+	EditSolve  = "solve"
+	EditAdd    = "add"
+	EditUpdate = "update"
+	EditRemove = "remove"
+)
+
+//EditImpact an Effect of an Edit on the Users who have Already Opened the Hint, Found the Flag or Solved the Challenge
+type EditImpact struct {
+	Kind   string
+	ID     string
+	Action string
+	Users  int
+}
+
+//ErrInvalidEdit an Error due to Edits which Miss or Duplicate Hints or Flags, or Refer to Unknown Ones
+var ErrInvalidEdit = fmt.Errorf("invalid edit of hints or flags")
+
+//ErrUnconfirmedEdit an Error due to Edits which Affect the Users but are not Confirmed
+var ErrUnconfirmedEdit = fmt.Errorf("the edit affects users who have already opened the hints, found the flags or solved the challenge")
+
+//newItemID Make a New ID of a Hint or a Flag of the Challenge, which is Independent of its Position
+func newItemID(challengeID string) string {
+	return challengeID + ":" + uuid.NewV4().String()
+}
+
+func orderPositions(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+//loadItems Load All the Hints and Flags of the Challenge including the Removed Ones
+func loadItems(tx *gorm.DB, challengeID string) (map[string]*Hint, map[string]*Flag, error) {
+	hints := make([]*Hint, 0)
+	if err := tx.Unscoped().Where(&Hint{ChallengeID: challengeID}).Find(&hints).Error; err != nil {
+		return nil, nil, err
+	}
+	flags := make([]*Flag, 0)
+	if err := tx.Unscoped().Where(&Flag{ChallengeID: challengeID}).Find(&flags).Error; err != nil {
+		return nil, nil, err
+	}
+
+	hintMap, flagMap := make(map[string]*Hint, len(hints)), make(map[string]*Flag, len(flags))
+	for _, hint := range hints {
+		hintMap[hint.ID] = hint
+	}
+	for _, _flag := range flags {
+		flagMap[_flag.ID] = _flag
+	}
+	return hintMap, flagMap, nil
+}
+
+//checkEdits Check that the Edits Mention Every Hint and Flag Exactly Once and Refer to No Unknown Ones
+func checkEdits(hints map[string]*Hint, flags map[string]*Flag, hintEdits []*HintEdit, flagEdits []*FlagEdit) error {
+	seen := make(map[string]struct{})
+	for _, edit := range hintEdits {
+		if edit.ID == "" {
+			if edit.Remove {
+				return ErrInvalidEdit
+			}
+			continue
+		}
+		if _, ok := hints[edit.ID]; !ok {
+			return ErrInvalidEdit
+		}
+		if _, ok := seen[edit.ID]; ok {
+			return ErrInvalidEdit
+		}
+		seen[edit.ID] = struct{}{}
+	}
+	for id, hint := range hints {
+		if _, ok := seen[id]; !ok && hint.DeletedAt == nil {
+			return ErrInvalidEdit
+		}
+	}
+
+	seen = make(map[string]struct{})
+	for _, edit := range flagEdits {
+		if edit.ID == "" {
+			if edit.Remove {
+				return ErrInvalidEdit
+			}
+			continue
+		}
+		if _, ok := flags[edit.ID]; !ok {
+			return ErrInvalidEdit
+		}
+		if _, ok := seen[edit.ID]; ok {
+			return ErrInvalidEdit
+		}
+		seen[edit.ID] = struct{}{}
+	}
+	for id, _flag := range flags {
+		if _, ok := seen[id]; !ok && _flag.DeletedAt == nil {
+			return ErrInvalidEdit
+		}
+	}
+	return nil
+}
+
+//sameTime Whether the Optional Times are the Same
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+//validateFlagEdits Validate the Flags which will be Kept after the Edits
+func validateFlagEdits(flagFormat string, flagEdits []*FlagEdit) error {
+	flags, matchModes, secrets := make([]string, 0, len(flagEdits)), make([]string, 0, len(flagEdits)), make([]string, 0, len(flagEdits))
+	for _, edit := range flagEdits {
+		if !edit.Remove {
+			flags, matchModes, secrets = append(flags, edit.Flag), append(matchModes, edit.MatchMode), append(secrets, edit.Secret)
+		}
+	}
+	return validateFlags(flagFormat, flags, matchModes, secrets)
+}
+
+//scoreAfter Get the Score of the Flag after the Edit, where a Full-Score Flag whose Score is Not Edited Follows the New Score of the Challenge
+func (edit *FlagEdit) scoreAfter(_flag *Flag, previousScore int, score int) int {
+	if _flag != nil && _flag.DeletedAt == nil && _flag.Score == previousScore && edit.Score == _flag.Score {
+		return score
+	}
+	return edit.Score
+}
+
+//solveHolders Get the Users who Hold a FoundFlag of the Flags, with the Time they Found the First One
+func solveHolders(tx *gorm.DB, flagIDs []string) (map[string]time.Time, error) {
+	solvedAt := make(map[string]time.Time)
+	if len(flagIDs) == 0 {
+		return solvedAt, nil
+	}
+	holders := make([]*struct {
+		UserID    string
+		CreatedAt time.Time
+	}, 0)
+	if err := tx.Table("found_flags").Select("user_found_flags.user_id, MIN(found_flags.created_at) AS created_at").Joins("JOIN user_found_flags ON user_found_flags.found_flag_id = found_flags.id").Where("found_flags.flag_id IN (?) AND found_flags.deleted_at IS NULL", flagIDs).Group("user_found_flags.user_id").Scan(&holders).Error; err != nil {
+		return nil, err
+	}
+	for _, holder := range holders {
+		solvedAt[holder.UserID] = holder.CreatedAt
+	}
+	return solvedAt, nil
+}
+
+//EditImpacts List the Effects of the Edits and the New Score of the Challenge on the Users who have Already Opened the Hints, Found the Flags or Solved the Challenge
+func (challenge *Challenge) EditImpacts(score int, hintEdits []*HintEdit, flagEdits []*FlagEdit) ([]*EditImpact, error) {
+	hints, flags, err := loadItems(db, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEdits(hints, flags, hintEdits, flagEdits); err != nil {
+		return nil, err
+	}
+
+	impacts := make([]*EditImpact, 0)
+	for _, edit := range hintEdits {
+		hint, ok := hints[edit.ID]
+		if !ok || hint.DeletedAt != nil {
+			continue
+		}
+		action := ""
+		if edit.Remove {
+			action = EditRemove
+		} else if edit.PenaltyPercent != hint.PenaltyPercent || edit.Cost != hint.Cost || !sameTime(edit.ReleaseAt, hint.ReleaseAt) {
+			action = EditUpdate
+		}
+		if action == "" {
+			continue
+		}
+		users := 0
+		if err := db.Model(&HintOpen{}).Where(&HintOpen{HintID: hint.ID}).Count(&users).Error; err != nil {
+			return nil, err
+		}
+		if users > 0 {
+			impacts = append(impacts, &EditImpact{Kind: EditHint, ID: hint.ID, Action: action, Users: users})
+		}
+	}
+	for _, edit := range flagEdits {
+		_flag, ok := flags[edit.ID]
+		if !ok || _flag.DeletedAt != nil {
+			continue
+		}
+		matchMode := map[bool]string{true: FlagExact, false: edit.MatchMode}[edit.MatchMode == ""]
+		action := ""
+		if edit.Remove {
+			action = EditRemove
+		} else if edit.Flag != _flag.Flag || matchMode != _flag.MatchMode || edit.Secret != _flag.Secret || edit.scoreAfter(_flag, challenge.Score, score) != _flag.Score {
+			action = EditUpdate
+		}
+		if action == "" {
+			continue
+		}
+		users := 0
+		if err := db.Table("user_found_flags").Joins("JOIN found_flags ON found_flags.id = user_found_flags.found_flag_id").Where("found_flags.flag_id = ? AND found_flags.deleted_at IS NULL", _flag.ID).Count(&users).Error; err != nil {
+			return nil, err
+		}
+		if users > 0 {
+			impacts = append(impacts, &EditImpact{Kind: EditFlag, ID: _flag.ID, Action: action, Users: users})
+		}
+	}
+
+	fullFlagIDs := make([]string, 0)
+	for _, edit := range flagEdits {
+		if _flag, ok := flags[edit.ID]; ok && !edit.Remove && edit.scoreAfter(_flag, challenge.Score, score) == score {
+			fullFlagIDs = append(fullFlagIDs, _flag.ID)
+		}
+	}
+	solvedAt, err := solveHolders(db, fullFlagIDs)
+	if err != nil {
+		return nil, err
+	}
+	solves := make([]*Solve, 0)
+	if err := db.Where(&Solve{ChallengeID: challenge.ID}).Find(&solves).Error; err != nil {
+		return nil, err
+	}
+	removed := 0
+	for _, solve := range solves {
+		if _, ok := solvedAt[solve.UserID]; ok {
+			delete(solvedAt, solve.UserID)
+		} else {
+			removed++
+		}
+	}
+	if removed > 0 {
+		impacts = append(impacts, &EditImpact{Kind: EditSolve, ID: challenge.ID, Action: EditRemove, Users: removed})
+	}
+	if len(solvedAt) > 0 {
+		impacts = append(impacts, &EditImpact{Kind: EditSolve, ID: challenge.ID, Action: EditAdd, Users: len(solvedAt)})
+	}
+	return impacts, nil
+}
+
+//applyEdits Make the Hint and Flag Records of the Challenge as the Edits, Positioned in the Order of them, where previousScore is the Score of the Challenge before the Update
+func applyEdits(tx *gorm.DB, challenge *Challenge, previousScore int, hintEdits []*HintEdit, flagEdits []*FlagEdit) error {
+	hints, flags, err := loadItems(tx, challenge.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkEdits(hints, flags, hintEdits, flagEdits); err != nil {
+		return err
+	}
+
+	challenge.Hints = make([]*Hint, 0, len(hintEdits))
+	for _, edit := range hintEdits {
+		if edit.Remove {
+			if err := tx.Delete(hints[edit.ID]).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		hint, ok := hints[edit.ID]
+		if !ok {
+			hint = &Hint{
+				ID:          newItemID(challenge.ID),
+				ChallengeID: challenge.ID,
+			}
+		}
+		hint.Caption, hint.PenaltyPercent, hint.Cost, hint.ReleaseAt, hint.Position, hint.DeletedAt = edit.Caption, edit.PenaltyPercent, edit.Cost, edit.ReleaseAt, len(challenge.Hints), nil
+		if err := tx.Unscoped().Save(hint).Error; err != nil {
+			return err
+		}
+		challenge.Hints = append(challenge.Hints, hint)
+	}
+
+	challenge.Flags = make([]*Flag, 0, len(flagEdits))
+	for _, edit := range flagEdits {
+		if edit.Remove {
+			if err := tx.Delete(flags[edit.ID]).Error; err != nil {
+				return err
+			}
+			if err := tx.Where(&FoundFlag{FlagID: edit.ID}).Delete(&FoundFlag{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		_flag, ok := flags[edit.ID]
+		score := edit.scoreAfter(_flag, previousScore, challenge.Score)
+		if !ok {
+			_flag = &Flag{
+				ID:          newItemID(challenge.ID),
+				ChallengeID: challenge.ID,
+			}
+		}
+		_flag.Flag, _flag.Secret, _flag.Score, _flag.Position, _flag.DeletedAt = edit.Flag, edit.Secret, score, len(challenge.Flags), nil
+		_flag.MatchMode = map[bool]string{true: FlagExact, false: edit.MatchMode}[edit.MatchMode == ""]
+		if err := tx.Unscoped().Save(_flag).Error; err != nil {
+			return err
+		}
+		challenge.Flags = append(challenge.Flags, _flag)
+	}
+	return nil
+}
+
+//penaltyOf Calculate the Hint Penalty of the FoundFlag from the Hints Opened by the Users before it was Found
+func penaltyOf(foundFlag *FoundFlag, userIDs []string, hintOpensOf map[string][]*HintOpen, hintMap map[string]*Hint) int {
+	opened := make(map[string]struct{})
+	penaltySum := 0
+	for _, userID := range userIDs {
+		for _, hintOpen := range hintOpensOf[userID] {
+			hint, ok := hintMap[hintOpen.HintID]
+			if !ok || hint.ChallengeID != foundFlag.ChallengeID {
+				continue
+			}
+			if hintOpen.CreatedAt != nil && hintOpen.CreatedAt.After(foundFlag.CreatedAt) {
+				continue
+			}
+			if _, ok := opened[hint.ID]; !ok {
+				opened[hint.ID] = struct{}{}
+				penaltySum += hint.PenaltyPercent
+			}
+		}
+	}
+	return penaltySum
+}
+
+//rebuildPenalties Recalculate the Hint Penalties of the FoundFlag Records of the Challenge from its Current Hints
+func rebuildPenalties(tx *gorm.DB, challengeID string) error {
+	hints := make([]*Hint, 0)
+	if err := tx.Where(&Hint{ChallengeID: challengeID}).Find(&hints).Error; err != nil {
+		return err
+	}
+	hintMap, hintIDs := make(map[string]*Hint, len(hints)), make([]string, len(hints))
+	for i, hint := range hints {
+		hintMap[hint.ID], hintIDs[i] = hint, hint.ID
+	}
+
+	foundFlags := make([]*FoundFlag, 0)
+	if err := tx.Where(&FoundFlag{ChallengeID: challengeID}).Find(&foundFlags).Error; err != nil {
+		return err
+	}
+	if len(foundFlags) == 0 {
+		return nil
+	}
+	foundFlagIDs := make([]int, len(foundFlags))
+	for i, foundFlag := range foundFlags {
+		foundFlagIDs[i] = foundFlag.ID
+	}
+	links := make([]*struct {
+		FoundFlagID int
+		UserID      string
+	}, 0)
+	if err := tx.Table("user_found_flags").Where("found_flag_id IN (?)", foundFlagIDs).Scan(&links).Error; err != nil {
+		return err
+	}
+	finderOf := make(map[int]string, len(links))
+	for _, link := range links {
+		finderOf[link.FoundFlagID] = link.UserID
+	}
+
+	hintOpens := make([]*HintOpen, 0)
+	if len(hintIDs) > 0 {
+		if err := tx.Where("hint_id IN (?)", hintIDs).Find(&hintOpens).Error; err != nil {
+			return err
+		}
+	}
+	hintOpensOf := make(map[string][]*HintOpen)
+	for _, hintOpen := range hintOpens {
+		hintOpensOf[hintOpen.UserID] = append(hintOpensOf[hintOpen.UserID], hintOpen)
+	}
+
+	for _, foundFlag := range foundFlags {
+		userID, ok := finderOf[foundFlag.ID]
+		if !ok {
+			continue
+		}
+		user := &User{}
+		if err := tx.Where(&User{ID: userID}).First(user).Error; err != nil {
+			return err
+		}
+		userIDs, err := teammateIDs(tx, user)
+		if err != nil {
+			return err
+		}
+		if penaltySum := penaltyOf(foundFlag, userIDs, hintOpensOf, hintMap); penaltySum != foundFlag.PenaltyPercent {
+			if err := tx.Model(foundFlag).UpdateColumn("penalty_percent", penaltySum).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//rebuildSolves Make the Solve Records of the Challenge Match the Users who Hold a FoundFlag of a Full-Score Flag of it, Dated when they Found the First One
+func rebuildSolves(tx *gorm.DB, challenge *Challenge) error {
+	fullFlagIDs := make([]string, 0)
+	if err := tx.Model(&Flag{}).Where(&Flag{ChallengeID: challenge.ID}).Where("score = ?", challenge.Score).Pluck("id", &fullFlagIDs).Error; err != nil {
+		return err
+	}
+	solvedAt, err := solveHolders(tx, fullFlagIDs)
+	if err != nil {
+		return err
+	}
+
+	solves := make([]*Solve, 0)
+	if err := tx.Where(&Solve{ChallengeID: challenge.ID}).Find(&solves).Error; err != nil {
+		return err
+	}
+	for _, solve := range solves {
+		if _, ok := solvedAt[solve.UserID]; ok {
+			delete(solvedAt, solve.UserID)
+			continue
+		}
+		if err := tx.Where(&Solve{UserID: solve.UserID, ChallengeID: challenge.ID}).Delete(&Solve{}).Error; err != nil {
+			return err
+		}
+	}
+	for userID, createdAt := range solvedAt {
+		if err := tx.Create(&Solve{UserID: userID, ChallengeID: challenge.ID, CreatedAt: createdAt}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package model
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,3 +18,67 @@ func FinishTime() time.Time {
 	finishTime, _ := time.Parse(time.RFC3339, os.Getenv("FINISH_TIME"))
 	return finishTime
 }
+
+//IsTeamMode Whether the Contest is Held in Team Mode
+func IsTeamMode() bool {
+	return os.Getenv("CONTEST_MODE") == "team"
+}
+
+//TeamSizeLimit How Many Users a Team can have at Most (0 means No Limit)
+func TeamSizeLimit() int {
+	limit, _ := strconv.Atoi(os.Getenv("TEAM_SIZE_LIMIT"))
+	return limit
+}
+
+//SubmissionRateLimit How Many Times a User can Submit Flags for a Challenge in How Long
+func SubmissionRateLimit() (int, time.Duration) {
+	return parseRateLimit(os.Getenv("SUBMISSION_RATE_LIMIT"))
+}
+
+//GlobalSubmissionRateLimit How Many Times a User can Submit Flags for Any Challenge in How Long
+func GlobalSubmissionRateLimit() (int, time.Duration) {
+	return parseRateLimit(os.Getenv("GLOBAL_SUBMISSION_RATE_LIMIT"))
+}
+
+//HintScoreFloor What Percentage of a Flag Score Remains at Least however Many Hints are Opened
+func HintScoreFloor() int {
+	floor, _ := strconv.Atoi(os.Getenv("HINT_SCORE_FLOOR"))
+	if floor < 0 || 100 < floor {
+		return 0
+	}
+	return floor
+}
+
+//QuestionSLA How Long a Question can Wait for an Answer before it is Overdue (0 means No Limit)
+func QuestionSLA() time.Duration {
+	seconds, _ := strconv.Atoi(os.Getenv("QUESTION_SLA"))
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+//AttachmentDir Where the Attachment Files are Stored on the Local Disk
+func AttachmentDir() string {
+	if dir := os.Getenv("ATTACHMENT_DIR"); dir != "" {
+		return dir
+	}
+	return "attachments"
+}
+
+//parseRateLimit Parse a Rate Limit in the Form of "<times>/<seconds>"
+func parseRateLimit(str string) (int, time.Duration) {
+	strSplit := strings.Split(str, "/")
+	if len(strSplit) != 2 {
+		return 0, 0
+	}
+	limit, err := strconv.Atoi(strSplit[0])
+	if err != nil {
+		return 0, 0
+	}
+	seconds, err := strconv.Atoi(strSplit[1])
+	if err != nil || seconds <= 0 {
+		return 0, 0
+	}
+	return limit, time.Duration(seconds) * time.Second
+}
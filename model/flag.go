@@ -0,0 +1,212 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//Match Modes of a Flag
+const (
+	FlagExact           = "exact"
+	FlagCaseInsensitive = "case_insensitive"
+	FlagRegex           = "regex"
+	FlagPerUser         = "per_user"
+)
+
+//FlagHMACPlaceholder the Placeholder in the Template of a Per-User Flag, which is Replaced with the HMAC of the User's ID
+const FlagHMACPlaceholder = "{{hmac}}"
+
+//SharingIncident a Record of a User's Submitting a Per-User Flag of Another User
+type SharingIncident struct {
+	ID           int `gorm:"primary_key"`
+	SubmitterID  string
+	Submitter    *User `gorm:"foreignkey:SubmitterID"`
+	OwnerID      string
+	Owner        *User `gorm:"foreignkey:OwnerID"`
+	ChallengeID  string
+	FlagID       string
+	SubmissionID int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    *time.Time
+}
+
+//ErrInvalidFlag an Error due to an Invalid Flag or Flag Format
+var ErrInvalidFlag = fmt.Errorf("invalid flag")
+
+func compileAnchored(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)$`)
+}
+
+func validateFlags(flagFormat string, flags []string, matchModes []string, secrets []string) error {
+	format, err := compileAnchored(flagFormat)
+	if err != nil {
+		return ErrInvalidFlag
+	}
+	for i, flag := range flags {
+		if flag == "" {
+			return ErrInvalidFlag
+		}
+		switch matchModes[i] {
+		case "", FlagExact, FlagCaseInsensitive:
+			if flagFormat != "" && !format.MatchString(flag) {
+				return ErrInvalidFlag
+			}
+		case FlagRegex:
+			if _, err := compileAnchored(flag); err != nil {
+				return ErrInvalidFlag
+			}
+		case FlagPerUser:
+			if secrets[i] == "" || !strings.Contains(flag, FlagHMACPlaceholder) {
+				return ErrInvalidFlag
+			}
+			if flagFormat != "" && !format.MatchString(strings.Replace(flag, FlagHMACPlaceholder, "00000000", -1)) {
+				return ErrInvalidFlag
+			}
+		default:
+			return ErrInvalidFlag
+		}
+	}
+	return nil
+}
+
+//FlagFor Get the Flag which the User should Submit
+func (_flag *Flag) FlagFor(userID string) string {
+	if _flag.MatchMode != FlagPerUser {
+		return _flag.Flag
+	}
+	mac := hmac.New(sha256.New, []byte(_flag.Secret))
+	mac.Write([]byte(userID))
+	return strings.Replace(_flag.Flag, FlagHMACPlaceholder, hex.EncodeToString(mac.Sum(nil))[:8], -1)
+}
+
+//Match Whether the Flag Submitted by the User Matches the Flag
+func (_flag *Flag) Match(submitted string, userID string) bool {
+	switch _flag.MatchMode {
+	case FlagCaseInsensitive:
+		return strings.EqualFold(_flag.Flag, submitted)
+	case FlagRegex:
+		re, err := compileAnchored(_flag.Flag)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(submitted)
+	case FlagPerUser:
+		return hmac.Equal([]byte(_flag.FlagFor(userID)), []byte(submitted))
+	}
+	return _flag.Flag == submitted
+}
+
+//MatchAny Whether the Flag Submitted by any of the Users Matches the Flag, which Lets Teammates Submit Each Other's Per-User Flags
+func (_flag *Flag) MatchAny(submitted string, userIDs []string) bool {
+	if _flag.MatchMode != FlagPerUser {
+		return _flag.Match(submitted, "")
+	}
+	for _, userID := range userIDs {
+		if _flag.Match(submitted, userID) {
+			return true
+		}
+	}
+	return false
+}
+
+//perUserFlagIndex the Users Keyed by their Flags of a Per-User Flag, which is Valid while the Flag, the Secret and the Number of the Users are Unchanged
+type perUserFlagIndex struct {
+	flag   string
+	secret string
+	users  int
+	owners map[string]string
+}
+
+var (
+	perUserFlagsMu    sync.Mutex
+	perUserFlagsCache = make(map[string]*perUserFlagIndex)
+)
+
+//perUserFlagOwners Get the IDs of the Users Keyed by their Flags of the Per-User Flag, Computing the Flags of All the Users Only when they have Changed
+func perUserFlagOwners(tx *gorm.DB, _flag *Flag, users int) (map[string]string, error) {
+	perUserFlagsMu.Lock()
+	index, ok := perUserFlagsCache[_flag.ID]
+	perUserFlagsMu.Unlock()
+	if ok && index.flag == _flag.Flag && index.secret == _flag.Secret && index.users == users {
+		return index.owners, nil
+	}
+
+	userIDs := make([]string, 0)
+	if err := tx.Model(&User{}).Pluck("id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	index = &perUserFlagIndex{
+		flag:   _flag.Flag,
+		secret: _flag.Secret,
+		users:  len(userIDs),
+		owners: make(map[string]string, len(userIDs)),
+	}
+	for _, userID := range userIDs {
+		index.owners[_flag.FlagFor(userID)] = userID
+	}
+	perUserFlagsMu.Lock()
+	perUserFlagsCache[_flag.ID] = index
+	perUserFlagsMu.Unlock()
+	return index.owners, nil
+}
+
+//findFlagOwner Find the User other than the Submitter and their Teammates whose Per-User Flag is the Submitted One
+func findFlagOwner(tx *gorm.DB, flags []*Flag, submitted string, submitterIDs []string) (string, *Flag, error) {
+	perUserFlags := make([]*Flag, 0)
+	for _, _flag := range flags {
+		if _flag.MatchMode == FlagPerUser {
+			placeholderIndex := strings.Index(_flag.Flag, FlagHMACPlaceholder)
+			prefix, suffix := _flag.Flag[:placeholderIndex], _flag.Flag[placeholderIndex+len(FlagHMACPlaceholder):]
+			if strings.HasPrefix(submitted, prefix) && strings.HasSuffix(submitted, suffix) {
+				perUserFlags = append(perUserFlags, _flag)
+			}
+		}
+	}
+	if len(perUserFlags) == 0 {
+		return "", nil, nil
+	}
+
+	users := 0
+	if err := tx.Model(&User{}).Count(&users).Error; err != nil {
+		return "", nil, err
+	}
+	submitters := make(map[string]struct{}, len(submitterIDs))
+	for _, userID := range submitterIDs {
+		submitters[userID] = struct{}{}
+	}
+	for _, _flag := range perUserFlags {
+		owners, err := perUserFlagOwners(tx, _flag, users)
+		if err != nil {
+			return "", nil, err
+		}
+		ownerID, ok := owners[submitted]
+		if _, isSubmitter := submitters[ownerID]; ok && !isSubmitter {
+			return ownerID, _flag, nil
+		}
+	}
+	return "", nil, nil
+}
+
+//MatchesFlagFormat Whether the Submitted Flag is in the Flag Format of the Challenge
+func (challenge *Challenge) MatchesFlagFormat(submitted string) bool {
+	if submitted == "" {
+		return false
+	}
+	if challenge.FlagFormat == "" {
+		return true
+	}
+	format, err := compileAnchored(challenge.FlagFormat)
+	if err != nil {
+		return false
+	}
+	return format.MatchString(submitted)
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	freezeMu sync.Mutex
+	unfrozen *bool
+)
+
+//FreezeTime When the Scoreboard is Frozen (Zero if it is Never Frozen)
+func FreezeTime() time.Time {
+	freezeTime, _ := time.Parse(time.RFC3339, os.Getenv("FREEZE_TIME"))
+	return freezeTime
+}
+
+//IsFrozen Whether the Scoreboard is Frozen Now
+func IsFrozen() (bool, error) {
+	freeze := FreezeTime()
+	if freeze.IsZero() || freeze.After(time.Now()) {
+		return false, nil
+	}
+
+	freezeMu.Lock()
+	defer freezeMu.Unlock()
+	if unfrozen == nil {
+		value, err := getSetting("unfrozen")
+		if err != nil {
+			return false, err
+		}
+		b := value == "true"
+		unfrozen = &b
+	}
+	return !*unfrozen, nil
+}
+
+//Unfreeze Unfreeze the Scoreboard
+func Unfreeze() error {
+	freezeMu.Lock()
+	defer freezeMu.Unlock()
+	if err := setSetting("unfrozen", "true"); err != nil {
+		return err
+	}
+	b := true
+	unfrozen = &b
+	return nil
+}
+
+//IsVisibleWhileFrozen Whether the Thing which Happened at the Time can be Shown while the Scoreboard is Frozen
+func IsVisibleWhileFrozen(t time.Time) bool {
+	return t.Before(FreezeTime())
+}
+
+//GetFrozenScore Get the Score of the User or the Team as of the Freeze
+func GetFrozenScore(id string) (int, error) {
+	standings, err := GetStandings(true)
+	if err != nil {
+		return 0, err
+	}
+	if standing, ok := standings[id]; ok {
+		return standing.Score, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//ErrInvalidHint an Error due to a Hint with an Invalid Penalty, Cost or Release Time
+var ErrInvalidHint = fmt.Errorf("invalid hint")
+
+//hintCost a Cost of a Hint Opened by a User
+type hintCost struct {
+	UserID    string
+	HintID    string
+	Cost      int
+	CreatedAt *time.Time
+}
+
+//validateHintEdits Validate the Hints which will be Kept after the Edits
+func validateHintEdits(hintEdits []*HintEdit) error {
+	for _, edit := range hintEdits {
+		if edit.Remove {
+			continue
+		}
+		if edit.PenaltyPercent < 0 || 100 < edit.PenaltyPercent || edit.Cost < 0 {
+			return ErrInvalidHint
+		}
+		if edit.ReleaseAt != nil && (edit.PenaltyPercent != 0 || edit.Cost != 0) {
+			return ErrInvalidHint
+		}
+	}
+	return nil
+}
+
+//IsFree Whether the Hint is Opened for Everyone for Free at its Release Time
+func (hint *Hint) IsFree() bool {
+	return hint.ReleaseAt != nil
+}
+
+//IsReleased Whether the Free Hint has been Released at the Time
+func (hint *Hint) IsReleased(t time.Time) bool {
+	return hint.ReleaseAt != nil && !hint.ReleaseAt.After(t)
+}
+
+//PenaltyPercentOf Sum the Percentage Penalties of the Hints
+func PenaltyPercentOf(hints []*Hint) int {
+	penaltySum := 0
+	for _, hint := range hints {
+		penaltySum += hint.PenaltyPercent
+	}
+	return penaltySum
+}
+
+//OpenedPenaltyPercent Sum the Percentage Penalties of the Hints of the Challenge which are in the Opened Map
+func (challenge *Challenge) OpenedPenaltyPercent(openedMap map[string]struct{}) int {
+	hints := make([]*Hint, 0, len(challenge.Hints))
+	for _, hint := range challenge.Hints {
+		if _, opened := openedMap[hint.ID]; opened {
+			hints = append(hints, hint)
+		}
+	}
+	return PenaltyPercentOf(hints)
+}
+
+//ApplyPenalty Calculate the Score Reduced by the Percentage Penalty, which is Capped so that the Floor of the Score Remains
+func ApplyPenalty(score int, penaltyPercent int) int {
+	if limit := 100 - HintScoreFloor(); penaltyPercent > limit {
+		penaltyPercent = limit
+	}
+	if penaltyPercent < 0 {
+		penaltyPercent = 0
+	}
+	return score * (100 - penaltyPercent) / 100
+}
+
+//getHintCosts Get the Costs of the Hints Opened by the Users (All Users if userIDs is nil) before the Time (Zero means Now)
+func getHintCosts(tx *gorm.DB, userIDs []string, until time.Time) ([]*hintCost, error) {
+	query := tx.Table("user_opened_hints").Select("user_opened_hints.user_id, user_opened_hints.hint_id, hints.cost, user_opened_hints.created_at").Joins("JOIN hints ON hints.id = user_opened_hints.hint_id").Where("hints.cost > 0 AND hints.deleted_at IS NULL")
+	if userIDs != nil {
+		query = query.Where("user_opened_hints.user_id IN (?)", userIDs)
+	}
+	if !until.IsZero() {
+		query = query.Where("user_opened_hints.created_at < ?", until)
+	}
+	costs := make([]*hintCost, 0)
+	if err := query.Order("user_opened_hints.created_at").Scan(&costs).Error; err != nil {
+		return nil, err
+	}
+	return costs, nil
+}
+
+//sumHintCosts Sum the Costs of the Hints, Counting Each Hint Only Once even if Several Users have Opened it
+func sumHintCosts(costs []*hintCost) int {
+	seen := make(map[string]struct{}, len(costs))
+	sum := 0
+	for _, cost := range costs {
+		if _, ok := seen[cost.HintID]; ok {
+			continue
+		}
+		seen[cost.HintID] = struct{}{}
+		sum += cost.Cost
+	}
+	return sum
+}
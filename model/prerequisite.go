@@ -0,0 +1,160 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+//Prerequisite a Record of a Condition to Unlock the Challenge, which Requires either Solving Another Challenge or Reaching the Score in the Genre
+type Prerequisite struct {
+	ID                  int `gorm:"primary_key"`
+	ChallengeID         string
+	RequiredChallengeID string
+	Genre               string
+	GenreScore          int
+}
+
+//Progress the Progress of a User or a Team Used to Judge whether Challenges are Unlocked, along with the Challenges in the Trash, which are No Longer Required
+type Progress struct {
+	Solved      map[string]struct{}
+	GenreScores map[string]int
+	Trashed     map[string]struct{}
+}
+
+//ErrInvalidPrerequisite an Error due to an Invalid Prerequisite
+var ErrInvalidPrerequisite = fmt.Errorf("invalid prerequisite")
+
+//ErrChallengeLocked an Error due to the Challenge Locked
+var ErrChallengeLocked = fmt.Errorf("the challenge is locked")
+
+//GetProgress Get the Progress of the User, or of their Team in Team Mode
+func GetProgress(user *User) (*Progress, error) {
+	progress := &Progress{
+		Solved:      make(map[string]struct{}),
+		GenreScores: make(map[string]int),
+		Trashed:     make(map[string]struct{}),
+	}
+	trashedIDs := make([]string, 0)
+	if err := db.Unscoped().Model(&Challenge{}).Where("deleted_at IS NOT NULL").Pluck("id", &trashedIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range trashedIDs {
+		progress.Trashed[id] = struct{}{}
+	}
+	if user.ID == Nobody.ID {
+		return progress, nil
+	}
+
+	userIDs, err := teammateIDs(db, user)
+	if err != nil {
+		return nil, err
+	}
+	solves := make([]*Solve, 0)
+	if err := db.Where("user_id IN (?)", userIDs).Find(&solves).Error; err != nil {
+		return nil, err
+	}
+	for _, solve := range solves {
+		progress.Solved[solve.ChallengeID] = struct{}{}
+	}
+
+	ownerID, scorerIDs, rankOf := user.ID, []string{user.ID}, func(challenge *Challenge) int { return challenge.SolveRank(user.ID) }
+	if IsTeamMode() && user.TeamID != "" {
+		ownerID = user.TeamID
+		scorerIDs, rankOf, err = teamScorers(db, user.TeamID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(scorerIDs) == 0 {
+		return progress, nil
+	}
+	genreScores, err := calcGenreScores(db, ownerID, scorerIDs, rankOf)
+	if err != nil {
+		return nil, err
+	}
+	progress.GenreScores = genreScores
+	return progress, nil
+}
+
+//IsUnlocked Whether All the Prerequisites of the Challenge are Satisfied by the Progress, Ignoring the Ones Requiring the Challenges in the Trash
+func (challenge *Challenge) IsUnlocked(progress *Progress) bool {
+	if _, solved := progress.Solved[challenge.ID]; solved {
+		return true
+	}
+	for _, prerequisite := range challenge.Prerequisites {
+		if _, trashed := progress.Trashed[prerequisite.RequiredChallengeID]; trashed {
+			continue
+		}
+		if prerequisite.RequiredChallengeID != "" {
+			if _, solved := progress.Solved[prerequisite.RequiredChallengeID]; !solved {
+				return false
+			}
+		} else if progress.GenreScores[prerequisite.Genre] < prerequisite.GenreScore {
+			return false
+		}
+	}
+	return true
+}
+
+//validatePrerequisites Validate the Prerequisites of the Challenge, Checking they do Not Make a Cycle
+func validatePrerequisites(tx *gorm.DB, challengeID string, prerequisites []*Prerequisite) error {
+	others := make([]*Prerequisite, 0)
+	if err := tx.Where("challenge_id <> ? AND required_challenge_id <> ''", challengeID).Find(&others).Error; err != nil {
+		return err
+	}
+	graph := make(map[string][]string)
+	for _, prerequisite := range others {
+		graph[prerequisite.ChallengeID] = append(graph[prerequisite.ChallengeID], prerequisite.RequiredChallengeID)
+	}
+
+	for _, prerequisite := range prerequisites {
+		if prerequisite.RequiredChallengeID == "" {
+			if prerequisite.Genre == "" || prerequisite.GenreScore <= 0 {
+				return ErrInvalidPrerequisite
+			}
+			continue
+		}
+		if prerequisite.RequiredChallengeID == challengeID {
+			return ErrInvalidPrerequisite
+		}
+		if err := tx.Where(&Challenge{ID: prerequisite.RequiredChallengeID}).First(&Challenge{}).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrInvalidPrerequisite
+			}
+			return err
+		}
+		graph[challengeID] = append(graph[challengeID], prerequisite.RequiredChallengeID)
+	}
+
+	visited := make(map[string]struct{})
+	stack := []string{challengeID}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, requiredID := range graph[id] {
+			if requiredID == challengeID {
+				return ErrInvalidPrerequisite
+			}
+			if _, ok := visited[requiredID]; !ok {
+				visited[requiredID] = struct{}{}
+				stack = append(stack, requiredID)
+			}
+		}
+	}
+	return nil
+}
+
+//replacePrerequisites Replace the Prerequisite Records of the Challenge
+func replacePrerequisites(tx *gorm.DB, challengeID string, prerequisites []*Prerequisite) error {
+	if err := tx.Where(&Prerequisite{ChallengeID: challengeID}).Delete(&Prerequisite{}).Error; err != nil {
+		return err
+	}
+	for _, prerequisite := range prerequisites {
+		prerequisite.ID, prerequisite.ChallengeID = 0, challengeID
+		if err := tx.Create(prerequisite).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,34 +1,82 @@
 package model
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/satori/go.uuid"
 )
 
-//Question a Question Record
+//Statuses of a Question
+const (
+	QuestionOpen     = "open"
+	QuestionAnswered = "answered"
+	QuestionClosed   = "closed"
+)
+
+//Question a Question Record, which is a Thread of Messages between the Questioner and the Authors
 type Question struct {
 	ID           string `gorm:"primary_key"`
 	QuestionerID string
 	Questioner   *User `gorm:"foreignkey:QuestionerID"`
+	ChallengeID  string
 	Publish      bool
+	Status       string
+	AssigneeID   string
+	Assignee     *User `gorm:"foreignkey:AssigneeID"`
+	AssignedAt   *time.Time
 	AnswererID   string
-	Answerer     *User `gorm:"foreignkey:AnswererID"`
-	Query        string
-	Answer       string
+	Answerer     *User  `gorm:"foreignkey:AnswererID"`
+	Query        string `sql:"type:text;"`
+	Messages     []*QuestionMessage
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    *time.Time
 }
 
+//QuestionMessage a Message in the Thread of a Question, which Only the Questioner and the Authors can See unless it is Broadcast
+type QuestionMessage struct {
+	ID         int `gorm:"primary_key"`
+	QuestionID string
+	UserID     string
+	User       *User  `gorm:"foreignkey:UserID"`
+	Body       string `sql:"type:text;"`
+	Broadcast  bool
+	CreatedAt  time.Time
+}
+
 //ErrQuestionNotFound an Error due to the Question Not Found
 var ErrQuestionNotFound = gorm.ErrRecordNotFound
 
+//ErrInvalidQuestion an Error due to an Empty Message, an Unknown Status or an Unknown Challenge
+var ErrInvalidQuestion = fmt.Errorf("invalid question")
+
+//ErrQuestionClosed an Error due to Posting a Message to the Closed Question
+var ErrQuestionClosed = fmt.Errorf("the question has been closed")
+
+//ErrQuestionClaimed an Error due to Handling the Question Assigned to Another Author
+var ErrQuestionClaimed = fmt.Errorf("the question has been claimed by another author")
+
+//QuestionMetrics the Response Times of a Question
+type QuestionMetrics struct {
+	TimeToAssign        *time.Duration
+	TimeToFirstResponse *time.Duration
+	MeanResponseTime    *time.Duration
+	MaxResponseTime     *time.Duration
+	WaitingSince        *time.Time
+	Overdue             bool
+}
+
+func orderMessages(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at").Order("id")
+}
+
 //GetQuestions Get All Question Records
 func GetQuestions() ([]*Question, error) {
 	questions := make([]*Question, 0)
-	if err := db.Preload("Questioner").Preload("Answerer").Order("updated_at desc").Find(&questions).Error; err != nil {
+	if err := db.Preload("Questioner").Preload("Assignee").Preload("Answerer").Preload("Messages", orderMessages).Preload("Messages.User").Order("updated_at desc").Find(&questions).Error; err != nil {
 		return nil, err
 	}
 	return questions, nil
@@ -37,42 +85,321 @@ func GetQuestions() ([]*Question, error) {
 //GetQuestionByID Get the Question Record by its ID
 func GetQuestionByID(id string) (*Question, error) {
 	question := new(Question)
-	if err := db.Where(&Question{ID: id}).Preload("Questioner").Preload("Answerer").First(question).Error; err != nil {
+	if err := db.Where(&Question{ID: id}).Preload("Questioner").Preload("Assignee").Preload("Answerer").Preload("Messages", orderMessages).Preload("Messages.User").First(question).Error; err != nil {
 		return nil, err
 	}
 	return question, nil
 }
 
-//NewQuestion Make a New Question Record
-func NewQuestion(questionerID string, query string) (*Question, error) {
+//challengeAuthor Get the Author of the Challenge the Question is about, Validating that the Challenge Exists, or nil if it is about No Challenge
+func challengeAuthor(tx *gorm.DB, challengeID string) (*User, error) {
+	if challengeID == "" {
+		return nil, nil
+	}
+	challenge := &Challenge{}
+	if err := tx.Where(&Challenge{ID: challengeID}).Preload("Author").First(challenge).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidQuestion
+		}
+		return nil, err
+	}
+	return challenge.Author, nil
+}
+
+//NewQuestion Make a New Question Record about the Challenge (Empty means the Whole Contest), Starting its Thread with the Query
+func NewQuestion(questionerID string, challengeID string, query string) (*Question, error) {
+	if query == "" {
+		return nil, ErrInvalidQuestion
+	}
 	id := uuid.NewV4().String()
 	questioner, err := GetUserByID(questionerID, false)
 	if err != nil {
 		return nil, err
 	}
+
+	tx := db.Begin()
+	assignee, err := challengeAuthor(tx, challengeID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	question := &Question{
 		ID:           id,
 		QuestionerID: questionerID,
-		Publish:      false,
 		Questioner:   questioner,
+		ChallengeID:  challengeID,
+		Publish:      false,
+		Status:       QuestionOpen,
 		Query:        query,
 	}
-	if err := db.Create(question).Error; err != nil {
+	if assignee != nil {
+		now := time.Now()
+		question.AssigneeID, question.Assignee, question.AssignedAt = assignee.ID, assignee, &now
+	}
+	if err := tx.Create(question).Error; err != nil {
+		tx.Rollback()
 		return nil, err
 	}
-	return question, nil
+	message := &QuestionMessage{
+		QuestionID: id,
+		UserID:     questionerID,
+		User:       questioner,
+		Body:       query,
+	}
+	if err := tx.Create(message).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	question.Messages = []*QuestionMessage{message}
+
+	return question, tx.Commit().Error
 }
 
-//Update Update the Question Record
-func (question *Question) Update(questionerID string, publish bool, answererID string, query string, answer string) error {
-	questioner, err := GetUserByID(questionerID, false)
+//PostMessage Add the User's Message to the Thread, which Answers the Question if the User is an Author, Claiming it unless Another Author has (or Taking it Over if force is true), and Broadcast it to Everyone if broadcast is true
+func (question *Question) PostMessage(user *User, body string, broadcast bool, force bool) (*QuestionMessage, error) {
+	if body == "" || (broadcast && !user.IsAuthor) {
+		return nil, ErrInvalidQuestion
+	}
+	if question.Status == QuestionClosed && !user.IsAuthor {
+		return nil, ErrQuestionClosed
+	}
+	if user.IsAuthor && question.AssigneeID != "" && question.AssigneeID != user.ID && !force {
+		return nil, ErrQuestionClaimed
+	}
+
+	tx := db.Begin()
+	message := &QuestionMessage{
+		QuestionID: question.ID,
+		UserID:     user.ID,
+		User:       user,
+		Body:       body,
+		Broadcast:  broadcast,
+	}
+	if err := tx.Create(message).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if user.IsAuthor {
+		question.Status, question.AnswererID, question.Answerer = QuestionAnswered, user.ID, user
+		question.Publish = question.Publish || broadcast
+		if question.AssigneeID != user.ID {
+			question.AssigneeID, question.Assignee, question.AssignedAt = user.ID, user, &message.CreatedAt
+		}
+	} else {
+		question.Status = QuestionOpen
+	}
+	if err := tx.Model(question).Updates(map[string]interface{}{"status": question.Status, "answerer_id": question.AnswererID, "publish": question.Publish, "assignee_id": question.AssigneeID, "assigned_at": question.AssignedAt}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	question.Messages = append(question.Messages, message)
+	return message, nil
+}
+
+//Update Update the Challenge, the Publicity and the Status of the Question Record, Leaving the Status Unchanged if it is Empty
+func (question *Question) Update(challengeID string, publish bool, status string) error {
+	if status == "" {
+		status = question.Status
+	}
+	switch status {
+	case QuestionOpen, QuestionAnswered, QuestionClosed:
+	default:
+		return ErrInvalidQuestion
+	}
+	assignee, err := challengeAuthor(db, challengeID)
 	if err != nil {
 		return err
 	}
-	answerer, err := GetUserByID(answererID, false)
-	if err != nil {
+	question.ChallengeID, question.Publish, question.Status = challengeID, publish, status
+	if assignee != nil && question.AssigneeID == "" {
+		now := time.Now()
+		question.AssigneeID, question.Assignee, question.AssignedAt = assignee.ID, assignee, &now
+	}
+	return db.Model(question).Updates(map[string]interface{}{"challenge_id": challengeID, "publish": publish, "status": status, "assignee_id": question.AssigneeID, "assigned_at": question.AssignedAt}).Error
+}
+
+//Claim Assign the Question to the Author unless Another Author has Claimed it, or Take it Over if force is true
+func (question *Question) Claim(author *User, force bool) error {
+	if question.AssigneeID == author.ID {
+		return nil
+	}
+	if question.AssigneeID != "" && !force {
+		return ErrQuestionClaimed
+	}
+
+	query := db.Model(&Question{}).Where(&Question{ID: question.ID})
+	if !force {
+		query = query.Where("assignee_id = '' OR assignee_id IS NULL")
+	}
+	now := time.Now()
+	result := query.Updates(map[string]interface{}{"assignee_id": author.ID, "assigned_at": &now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrQuestionClaimed
+	}
+	question.AssigneeID, question.Assignee, question.AssignedAt = author.ID, author, &now
+	return nil
+}
+
+//Unclaim Release the Question Assigned to the Author, or Assigned to Anyone if force is true
+func (question *Question) Unclaim(author *User, force bool) error {
+	if question.AssigneeID == "" {
+		return nil
+	}
+	if question.AssigneeID != author.ID && !force {
+		return ErrQuestionClaimed
+	}
+	question.AssigneeID, question.Assignee, question.AssignedAt = "", nil, nil
+	return db.Model(question).Updates(map[string]interface{}{"assignee_id": "", "assigned_at": nil}).Error
+}
+
+//Metrics Calculate the Response Times of the Question from its Thread, where Every Message Not by the Questioner is by an Author
+func (question *Question) Metrics(now time.Time) *QuestionMetrics {
+	metrics := &QuestionMetrics{}
+	if question.AssignedAt != nil {
+		d := question.AssignedAt.Sub(question.CreatedAt)
+		metrics.TimeToAssign = &d
+	}
+
+	var sum, max time.Duration
+	responses := 0
+	var waitingSince *time.Time
+	for _, message := range question.Messages {
+		if message.UserID == question.QuestionerID {
+			if waitingSince == nil {
+				createdAt := message.CreatedAt
+				waitingSince = &createdAt
+			}
+			continue
+		}
+		if waitingSince == nil {
+			continue
+		}
+		d := message.CreatedAt.Sub(*waitingSince)
+		if metrics.TimeToFirstResponse == nil {
+			first := message.CreatedAt.Sub(question.CreatedAt)
+			metrics.TimeToFirstResponse = &first
+		}
+		sum += d
+		if max < d {
+			max = d
+		}
+		responses++
+		waitingSince = nil
+	}
+	if responses > 0 {
+		mean := sum / time.Duration(responses)
+		metrics.MeanResponseTime, metrics.MaxResponseTime = &mean, &max
+	}
+
+	if question.Status != QuestionClosed {
+		metrics.WaitingSince = waitingSince
+	}
+	if sla := QuestionSLA(); sla > 0 && metrics.WaitingSince != nil {
+		metrics.Overdue = now.Sub(*metrics.WaitingSince) > sla
+	}
+	return metrics
+}
+
+//GetQuestionQueue Get the Questions which are Not Closed and are either Unclaimed or Waiting for an Answer, the Longest Waiting First
+func GetQuestionQueue(now time.Time) ([]*Question, error) {
+	questions := make([]*Question, 0)
+	if err := db.Where("status <> ?", QuestionClosed).Preload("Questioner").Preload("Assignee").Preload("Answerer").Preload("Messages", orderMessages).Preload("Messages.User").Find(&questions).Error; err != nil {
+		return nil, err
+	}
+
+	queue := make([]*Question, 0, len(questions))
+	since := make(map[string]time.Time, len(questions))
+	for _, question := range questions {
+		metrics := question.Metrics(now)
+		if question.AssigneeID != "" && metrics.WaitingSince == nil {
+			continue
+		}
+		since[question.ID] = question.CreatedAt
+		if metrics.WaitingSince != nil {
+			since[question.ID] = *metrics.WaitingSince
+		}
+		queue = append(queue, question)
+	}
+	sort.SliceStable(queue, func(i, j int) bool { return since[queue[i].ID].Before(since[queue[j].ID]) })
+	return queue, nil
+}
+
+//Close Close the Question
+func (question *Question) Close() error {
+	question.Status = QuestionClosed
+	return db.Model(question).Update("status", QuestionClosed).Error
+}
+
+//IsVisibleTo Whether the User can See the Question
+func (question *Question) IsVisibleTo(user *User) bool {
+	return question.Publish || question.QuestionerID == user.ID || user.IsAuthor
+}
+
+//VisibleMessages Get the Messages of the Thread which the User can See, which are the Query and the Broadcast Ones if the User is Neither the Questioner Nor an Author
+func (question *Question) VisibleMessages(user *User) []*QuestionMessage {
+	if question.QuestionerID == user.ID || user.IsAuthor {
+		return question.Messages
+	}
+	messages := make([]*QuestionMessage, 0, len(question.Messages))
+	if !question.Publish {
+		return messages
+	}
+	for i, message := range question.Messages {
+		if i == 0 || message.Broadcast {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+//migrateQuestions Make the Threads of the Questions in the Old Form of a Single Query and Answer Pair
+func migrateQuestions() error {
+	if !db.Dialect().HasColumn("questions", "answer") {
+		return nil
+	}
+	rows := make([]*struct {
+		ID           string
+		QuestionerID string
+		AnswererID   string
+		Query        string
+		Answer       string
+		Publish      bool
+		CreatedAt    time.Time
+		UpdatedAt    time.Time
+	}, 0)
+	if err := db.Table("questions").Select("id, questioner_id, answerer_id, query, answer, publish, created_at, updated_at").Where("status = '' OR status IS NULL").Scan(&rows).Error; err != nil {
 		return err
 	}
-	question.QuestionerID, question.Questioner, question.Publish, question.AnswererID, question.Answerer, question.Query, question.Answer = questionerID, questioner, publish, answererID, answerer, query, answer
-	return db.Save(question).Error
+	for _, row := range rows {
+		tx := db.Begin()
+		status := QuestionOpen
+		messages := []*QuestionMessage{{QuestionID: row.ID, UserID: row.QuestionerID, Body: row.Query, CreatedAt: row.CreatedAt}}
+		if row.Answer != "" {
+			status = QuestionAnswered
+			messages = append(messages, &QuestionMessage{QuestionID: row.ID, UserID: row.AnswererID, Body: row.Answer, Broadcast: row.Publish, CreatedAt: row.UpdatedAt})
+		}
+		for _, message := range messages {
+			if err := tx.Create(message).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.Table("questions").Where("id = ?", row.ID).UpdateColumn("status", status).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"sort"
+)
+
+//RankingEntry an Entry of the Ranking, which has either a User or a Team
+type RankingEntry struct {
+	Rank     int
+	User     *User
+	Team     *Team
+	Standing *Standing
+}
+
+//ID the ID of the User or the Team of the Entry
+func (entry *RankingEntry) ID() string {
+	if entry.Team != nil {
+		return entry.Team.ID
+	}
+	return entry.User.ID
+}
+
+//Name the Name of the User or the Team of the Entry
+func (entry *RankingEntry) Name() string {
+	if entry.Team != nil {
+		return entry.Team.Name
+	}
+	return entry.User.Name
+}
+
+//GetRanking Get the Ranking of the Users except Authors, as of the Freeze if frozen is true, Filtered by Whether they are Onsite unless onsite is nil
+func GetRanking(frozen bool, onsite *bool) ([]*RankingEntry, error) {
+	standings, err := GetStandings(frozen)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*User, 0)
+	query := db.Where("is_author = ?", false)
+	if onsite != nil {
+		query = query.Where("is_onsite = ?", *onsite)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]*RankingEntry, len(users))
+	for i, user := range users {
+		entries[i] = &RankingEntry{
+			User:     user,
+			Standing: standings[user.ID],
+		}
+	}
+	rankEntries(entries)
+	return entries, nil
+}
+
+//GetTeamRanking Get the Ranking of the Teams with Members Other than Authors, Counting Only those Members, and of the Users without Teams except Authors as Teams of their Own, as of the Freeze if frozen is true
+func GetTeamRanking(frozen bool) ([]*RankingEntry, error) {
+	standings, err := GetStandings(frozen)
+	if err != nil {
+		return nil, err
+	}
+
+	teams := make([]*Team, 0)
+	if err := db.Preload("Members").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	users := make([]*User, 0)
+	if err := db.Where("is_author = ? AND team_id = ''", false).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]*RankingEntry, 0, len(teams)+len(users))
+	for _, team := range teams {
+		for _, member := range team.Members {
+			if !member.IsAuthor {
+				entries = append(entries, &RankingEntry{
+					Team:     team,
+					Standing: standings[team.ID],
+				})
+				break
+			}
+		}
+	}
+	for _, user := range users {
+		entries = append(entries, &RankingEntry{
+			User:     user,
+			Standing: standings[user.ID],
+		})
+	}
+	rankEntries(entries)
+	return entries, nil
+}
+
+//rankEntries Sort the Entries by their Scores and the Time they Reached the Scores, and Number them
+func rankEntries(entries []*RankingEntry) {
+	for _, entry := range entries {
+		if entry.Standing == nil {
+			entry.Standing = &Standing{GenreScores: make(map[string]int)}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Standing.Score != entries[j].Standing.Score {
+			return entries[i].Standing.Score > entries[j].Standing.Score
+		}
+		if !entries[i].Standing.ReachedAt.Equal(entries[j].Standing.ReachedAt) {
+			return entries[i].Standing.ReachedAt.Before(entries[j].Standing.ReachedAt)
+		}
+		return entries[i].ID() < entries[j].ID()
+	})
+
+	for i, entry := range entries {
+		entry.Rank = i + 1
+		if i > 0 {
+			prev := entries[i-1]
+			if prev.Standing.Score == entry.Standing.Score && prev.Standing.ReachedAt.Equal(entry.Standing.ReachedAt) {
+				entry.Rank = prev.Rank
+			}
+		}
+	}
+}
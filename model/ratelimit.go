@@ -0,0 +1,89 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+//RateLimiter a Store of Counters for Rate Limiting, which may be Shared among Multiple Instances
+type RateLimiter interface {
+	//Hit Count up the Key, and Return the Count in the Current Window and When the Window Ends
+	Hit(key string, window time.Duration) (int, time.Time, error)
+}
+
+type memoryRateLimitWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+//MemoryRateLimiter a RateLimiter which Keeps its Counters in Memory
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryRateLimitWindow
+	lastSweep time.Time
+}
+
+//NewMemoryRateLimiter Make a New MemoryRateLimiter
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		windows:   make(map[string]*memoryRateLimitWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+//Hit Count up the Key, and Return the Count in the Current Window and When the Window Ends
+func (limiter *MemoryRateLimiter) Hit(key string, window time.Duration) (int, time.Time, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(limiter.lastSweep) > time.Minute {
+		for k, w := range limiter.windows {
+			if !w.resetAt.After(now) {
+				delete(limiter.windows, k)
+			}
+		}
+		limiter.lastSweep = now
+	}
+
+	w, ok := limiter.windows[key]
+	if !ok || !w.resetAt.After(now) {
+		w = &memoryRateLimitWindow{
+			resetAt: now.Add(window),
+		}
+		limiter.windows[key] = w
+	}
+	w.count++
+	return w.count, w.resetAt, nil
+}
+
+var rateLimiter RateLimiter = NewMemoryRateLimiter()
+
+//SetRateLimiter Replace the Store used for Rate Limiting
+func SetRateLimiter(limiter RateLimiter) {
+	rateLimiter = limiter
+}
+
+//CheckSubmissionRate Count up the User's Submissions, and Return How Long they have to Wait if they have Submitted Too Many Times
+func CheckSubmissionRate(userID string, challengeID string) (time.Duration, error) {
+	wait := time.Duration(0)
+	if limit, window := GlobalSubmissionRateLimit(); limit > 0 {
+		count, resetAt, err := rateLimiter.Hit("submission:"+userID, window)
+		if err != nil {
+			return 0, err
+		}
+		if count > limit {
+			wait = time.Until(resetAt)
+		}
+	}
+	if limit, window := SubmissionRateLimit(); limit > 0 {
+		count, resetAt, err := rateLimiter.Hit("submission:"+userID+":"+challengeID, window)
+		if err != nil {
+			return 0, err
+		}
+		if count > limit && wait < time.Until(resetAt) {
+			wait = time.Until(resetAt)
+		}
+	}
+	return wait, nil
+}
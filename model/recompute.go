@@ -0,0 +1,139 @@
+package model
+
+//ScoreDiff a Difference between the Stored Score and the Recomputed One
+type ScoreDiff struct {
+	ID         string
+	Name       string
+	IsTeam     bool
+	Stored     int
+	Recomputed int
+}
+
+//RecomputeReport a Report of Recomputing the Scores
+type RecomputeReport struct {
+	DryRun         bool
+	FixedPenalties int
+	Diffs          []*ScoreDiff
+}
+
+//RecomputeScores Rebuild the Hint Penalties of the FoundFlag Records and the Scores of All Users and Teams, and Store them unless dryRun is true
+func RecomputeScores(dryRun bool) (*RecomputeReport, error) {
+	report := &RecomputeReport{
+		DryRun: dryRun,
+		Diffs:  make([]*ScoreDiff, 0),
+	}
+
+	tx := db.Begin()
+
+	hints := make([]*Hint, 0)
+	if err := tx.Find(&hints).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	hintMap := make(map[string]*Hint, len(hints))
+	for _, hint := range hints {
+		hintMap[hint.ID] = hint
+	}
+
+	hintOpens := make([]*HintOpen, 0)
+	if err := tx.Find(&hintOpens).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	hintOpensOf := make(map[string][]*HintOpen)
+	for _, hintOpen := range hintOpens {
+		hintOpensOf[hintOpen.UserID] = append(hintOpensOf[hintOpen.UserID], hintOpen)
+	}
+
+	users := make([]*User, 0)
+	if err := tx.Find(&users).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	membersOf := make(map[string][]string)
+	for _, user := range users {
+		if user.TeamID != "" {
+			membersOf[user.TeamID] = append(membersOf[user.TeamID], user.ID)
+		}
+	}
+
+	for _, user := range users {
+		userIDs := []string{user.ID}
+		if IsTeamMode() && user.TeamID != "" {
+			userIDs = membersOf[user.TeamID]
+		}
+
+		foundFlags := make([]*FoundFlag, 0)
+		if err := tx.Joins("JOIN user_found_flags ON user_found_flags.found_flag_id = found_flags.id").Where("user_found_flags.user_id = ?", user.ID).Find(&foundFlags).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		for _, foundFlag := range foundFlags {
+			penaltySum := penaltyOf(foundFlag, userIDs, hintOpensOf, hintMap)
+			if foundFlag.PenaltyPercent != penaltySum {
+				report.FixedPenalties++
+				if err := tx.Model(foundFlag).UpdateColumn("penalty_percent", penaltySum).Error; err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, user := range users {
+		score, err := calcUserScore(tx, user.ID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if score == user.Score {
+			continue
+		}
+		report.Diffs = append(report.Diffs, &ScoreDiff{
+			ID:         user.ID,
+			Name:       user.Name,
+			Stored:     user.Score,
+			Recomputed: score,
+		})
+		if err := tx.Model(user).UpdateColumn("score", score).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	teams := make([]*Team, 0)
+	if err := tx.Find(&teams).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for _, team := range teams {
+		score, err := calcTeamScore(tx, team.ID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if score == team.Score {
+			continue
+		}
+		report.Diffs = append(report.Diffs, &ScoreDiff{
+			ID:         team.ID,
+			Name:       team.Name,
+			IsTeam:     true,
+			Stored:     team.Score,
+			Recomputed: score,
+		})
+		if err := tx.Model(team).UpdateColumn("score", score).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return report, tx.Rollback().Error
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	invalidateScoreCaches()
+	return report, nil
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//ErrInvalidSchedule an Error due to an Invalid Release Schedule
+var ErrInvalidSchedule = fmt.Errorf("invalid release schedule")
+
+func validateSchedule(releaseAt *time.Time, hideAt *time.Time) error {
+	if releaseAt != nil && hideAt != nil && !hideAt.After(*releaseAt) {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+//IsReleased Whether the Challenge is Visible to Participants at the Time
+func (challenge *Challenge) IsReleased(t time.Time) bool {
+	if challenge.ReleaseAt != nil && challenge.ReleaseAt.After(t) {
+		return false
+	}
+	if challenge.HideAt != nil && !challenge.HideAt.After(t) {
+		return false
+	}
+	return true
+}
+
+//GetReleasedChallenges Get the Challenges Released in the Period (since, until]
+func GetReleasedChallenges(since time.Time, until time.Time) ([]*Challenge, error) {
+	challenges := make([]*Challenge, 0)
+	if err := db.Where("release_at > ? AND release_at <= ?", since, until).Order("release_at").Find(&challenges).Error; err != nil {
+		return nil, err
+	}
+	return challenges, nil
+}
+
+//GetNextReleaseTime Get the Time when the Next Challenge will be Released after the Time, or nil if there is None
+func GetNextReleaseTime(after time.Time) (*time.Time, error) {
+	challenge := &Challenge{}
+	if err := db.Where("release_at > ?", after).Order("release_at").First(challenge).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return challenge.ReleaseAt, nil
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Kinds of an Incident
+const (
+	IncidentCloseSolves         = "close_solves"
+	IncidentSolveWithoutOpening = "solve_without_opening"
+	IncidentWrongChallengeFlag  = "wrong_challenge_flag"
+	IncidentIdenticalWrongFlags = "identical_wrong_flags"
+	IncidentFlagSharing         = "flag_sharing"
+)
+
+//Incident a Suspicious Pattern Found in the Records
+type Incident struct {
+	Kind        string
+	Time        time.Time
+	ChallengeID string
+	UserIDs     []string
+	Evidence    []string
+}
+
+type userChallengePair struct {
+	UserID      string
+	ChallengeID string
+}
+
+type flagChallengePair struct {
+	Flag        string
+	ChallengeID string
+}
+
+//GetCheatingReport Find Suspicious Patterns in the Records, Regarding Solves within the Window as Close Ones
+func GetCheatingReport(window time.Duration) ([]*Incident, error) {
+	challenges, err := GetChallenges()
+	if err != nil {
+		return nil, err
+	}
+
+	authors := make(map[string]struct{})
+	authorIDs := make([]string, 0)
+	if err := db.Model(&User{}).Where(&User{IsAuthor: true}).Pluck("id", &authorIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, authorID := range authorIDs {
+		authors[authorID] = struct{}{}
+	}
+
+	//The Opens have been Recorded with their Times Only Recently, so Solves before the First Recorded Open of the User cannot be Judged
+	opened, firstOpened := make(map[userChallengePair]struct{}), make(map[string]time.Time)
+	challengeOpens := make([]*ChallengeOpen, 0)
+	if err := db.Find(&challengeOpens).Error; err != nil {
+		return nil, err
+	}
+	for _, challengeOpen := range challengeOpens {
+		opened[userChallengePair{UserID: challengeOpen.UserID, ChallengeID: challengeOpen.ChallengeID}] = struct{}{}
+		if challengeOpen.CreatedAt == nil {
+			continue
+		}
+		if first, ok := firstOpened[challengeOpen.UserID]; !ok || challengeOpen.CreatedAt.Before(first) {
+			firstOpened[challengeOpen.UserID] = *challengeOpen.CreatedAt
+		}
+	}
+
+	incidents := make([]*Incident, 0)
+
+	for _, challenge := range challenges {
+		solves := make([]*Solve, 0, len(challenge.Solves))
+		for _, solve := range challenge.Solves {
+			if _, isAuthor := authors[solve.UserID]; !isAuthor {
+				solves = append(solves, solve)
+			}
+		}
+
+		for i, solve := range solves {
+			for _, another := range solves[i+1:] {
+				if another.CreatedAt.Sub(solve.CreatedAt) > window {
+					break
+				}
+				incidents = append(incidents, &Incident{
+					Kind:        IncidentCloseSolves,
+					Time:        another.CreatedAt,
+					ChallengeID: challenge.ID,
+					UserIDs:     []string{solve.UserID, another.UserID},
+					Evidence: []string{
+						fmt.Sprintf("%s solved at %s", solve.UserID, solve.CreatedAt.Format(time.RFC3339)),
+						fmt.Sprintf("%s solved at %s", another.UserID, another.CreatedAt.Format(time.RFC3339)),
+					},
+				})
+			}
+
+			first, ok := firstOpened[solve.UserID]
+			if !ok || solve.CreatedAt.Before(first) {
+				continue
+			}
+			if _, ok := opened[userChallengePair{UserID: solve.UserID, ChallengeID: challenge.ID}]; !ok {
+				incidents = append(incidents, &Incident{
+					Kind:        IncidentSolveWithoutOpening,
+					Time:        solve.CreatedAt,
+					ChallengeID: challenge.ID,
+					UserIDs:     []string{solve.UserID},
+					Evidence: []string{
+						fmt.Sprintf("%s solved at %s without opening the challenge", solve.UserID, solve.CreatedAt.Format(time.RFC3339)),
+					},
+				})
+			}
+		}
+	}
+
+	submissions := make([]*Submission, 0)
+	if err := db.Where("is_correct = ?", false).Order("created_at").Find(&submissions).Error; err != nil {
+		return nil, err
+	}
+
+	identicals := make(map[flagChallengePair][]*Submission)
+	for _, submission := range submissions {
+		if _, isAuthor := authors[submission.UserID]; isAuthor {
+			continue
+		}
+
+		for _, challenge := range challenges {
+			if challenge.ID == submission.ChallengeID {
+				continue
+			}
+			for _, _flag := range challenge.Flags {
+				if _flag.Match(submission.Flag, submission.UserID) {
+					incidents = append(incidents, &Incident{
+						Kind:        IncidentWrongChallengeFlag,
+						Time:        submission.CreatedAt,
+						ChallengeID: submission.ChallengeID,
+						UserIDs:     []string{submission.UserID},
+						Evidence: []string{
+							fmt.Sprintf("submission #%d of %s at %s matches the flag %s of the challenge %s", submission.ID, submission.UserID, submission.CreatedAt.Format(time.RFC3339), _flag.ID, challenge.ID),
+						},
+					})
+				}
+			}
+		}
+
+		key := flagChallengePair{Flag: submission.Flag, ChallengeID: submission.ChallengeID}
+		identicals[key] = append(identicals[key], submission)
+	}
+
+	for key, _submissions := range identicals {
+		userIDs := make([]string, 0)
+		userMap := make(map[string]struct{})
+		evidence := make([]string, len(_submissions))
+		for i, submission := range _submissions {
+			if _, ok := userMap[submission.UserID]; !ok {
+				userMap[submission.UserID] = struct{}{}
+				userIDs = append(userIDs, submission.UserID)
+			}
+			evidence[i] = fmt.Sprintf("submission #%d of %s at %s", submission.ID, submission.UserID, submission.CreatedAt.Format(time.RFC3339))
+		}
+		if len(userIDs) < 2 {
+			continue
+		}
+		incidents = append(incidents, &Incident{
+			Kind:        IncidentIdenticalWrongFlags,
+			Time:        _submissions[len(_submissions)-1].CreatedAt,
+			ChallengeID: key.ChallengeID,
+			UserIDs:     userIDs,
+			Evidence:    append([]string{fmt.Sprintf("the wrong flag %q was submitted by %s", key.Flag, strings.Join(userIDs, ", "))}, evidence...),
+		})
+	}
+
+	sharingIncidents := make([]*SharingIncident, 0)
+	if err := db.Order("created_at").Find(&sharingIncidents).Error; err != nil {
+		return nil, err
+	}
+	for _, sharingIncident := range sharingIncidents {
+		incidents = append(incidents, &Incident{
+			Kind:        IncidentFlagSharing,
+			Time:        sharingIncident.CreatedAt,
+			ChallengeID: sharingIncident.ChallengeID,
+			UserIDs:     []string{sharingIncident.SubmitterID, sharingIncident.OwnerID},
+			Evidence: []string{
+				fmt.Sprintf("submission #%d of %s matches the flag %s derived for %s", sharingIncident.SubmissionID, sharingIncident.SubmitterID, sharingIncident.FlagID, sharingIncident.OwnerID),
+			},
+		})
+	}
+
+	sort.SliceStable(incidents, func(i, j int) bool { return incidents[i].Time.Before(incidents[j].Time) })
+	return incidents, nil
+}
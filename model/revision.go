@@ -0,0 +1,153 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"gopkg.in/yaml.v2"
+)
+
+//Revision a Record of an Update of a Challenge, which Keeps the Snapshot before the Update
+type Revision struct {
+	ID          int `gorm:"primary_key"`
+	ChallengeID string
+	EditorID    string
+	Editor      *User `gorm:"foreignkey:EditorID"`
+	Changes     string
+	Snapshot    string `sql:"type:text;"`
+	CreatedAt   time.Time
+	Diffs       []*FieldDiff `gorm:"-"`
+}
+
+//FieldDiff a Difference of a Field of a Challenge, whose Values are in YAML
+type FieldDiff struct {
+	Field  string
+	Before string
+	After  string
+}
+
+//ErrRevisionNotFound an Error due to the Revision Not Found
+var ErrRevisionNotFound = gorm.ErrRecordNotFound
+
+//snapshotChallenge Make the Bundle of the Challenge as it is Now
+func snapshotChallenge(tx *gorm.DB, challenge *Challenge) (*Bundle, error) {
+	ids := make([]string, 0, len(challenge.Prerequisites))
+	for _, prerequisite := range challenge.Prerequisites {
+		if prerequisite.RequiredChallengeID != "" {
+			ids = append(ids, prerequisite.RequiredChallengeID)
+		}
+	}
+	required := make(map[string]*Challenge)
+	if len(ids) > 0 {
+		challenges := make([]*Challenge, 0)
+		if err := tx.Unscoped().Where("id IN (?)", ids).Find(&challenges).Error; err != nil {
+			return nil, err
+		}
+		for _, c := range challenges {
+			required[c.ID] = c
+		}
+	}
+	return newBundle(challenge, required), nil
+}
+
+//recordRevision Record the Update of the Challenge from the Snapshot, unless Nothing has been Changed
+func recordRevision(tx *gorm.DB, challenge *Challenge, before *Bundle, editorID string) error {
+	after, err := snapshotChallenge(tx, challenge)
+	if err != nil {
+		return err
+	}
+	changes := diffBundles(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	data, err := yaml.Marshal(before)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&Revision{
+		ChallengeID: challenge.ID,
+		EditorID:    editorID,
+		Changes:     strings.Join(changes, ","),
+		Snapshot:    string(data),
+	}).Error
+}
+
+//ChangedFields Get the Names of the Fields Changed in the Update
+func (revision *Revision) ChangedFields() []string {
+	if revision.Changes == "" {
+		return []string{}
+	}
+	return strings.Split(revision.Changes, ",")
+}
+
+//Bundle Parse the Snapshot of the Revision
+func (revision *Revision) Bundle() (*Bundle, error) {
+	bundle := &Bundle{}
+	if err := yaml.Unmarshal([]byte(revision.Snapshot), bundle); err != nil {
+		return nil, err
+	}
+	bundle.normalize()
+	return bundle, nil
+}
+
+//GetRevisions Get the Revisions of the Challenge from the Newest, with the Diffs from their Snapshots to the Next States
+func (challenge *Challenge) GetRevisions() ([]*Revision, error) {
+	revisions := make([]*Revision, 0)
+	if err := db.Where(&Revision{ChallengeID: challenge.ID}).Preload("Editor").Order("id desc").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+
+	after, err := snapshotChallenge(db, challenge)
+	if err != nil {
+		return nil, err
+	}
+	for _, revision := range revisions {
+		before, err := revision.Bundle()
+		if err != nil {
+			return nil, err
+		}
+		revision.Diffs = diffFields(before, after)
+		after = before
+	}
+	return revisions, nil
+}
+
+//Rollback Restore the Challenge to the Snapshot of the Revision, which is Recorded as a New Revision by the Editor.
+//If the Rollback Affects the Users who have Already Opened the Hints or Found the Flags, Nothing is Written unless confirm is true, and the Effects are Returned with ErrUnconfirmedEdit
+func (challenge *Challenge) Rollback(revisionID int, editorID string, confirm bool) ([]*EditImpact, error) {
+	revision := &Revision{}
+	if err := db.Where(&Revision{ID: revisionID, ChallengeID: challenge.ID}).First(revision).Error; err != nil {
+		return nil, err
+	}
+	bundle, err := revision.Bundle()
+	if err != nil {
+		return nil, err
+	}
+	impacts, err := bundle.impacts(challenge)
+	if err != nil {
+		return nil, err
+	}
+	if len(impacts) > 0 && !confirm {
+		return impacts, ErrUnconfirmedEdit
+	}
+
+	challenges := make([]*Challenge, 0)
+	if err := db.Find(&challenges).Error; err != nil {
+		return nil, err
+	}
+	ids := make(map[challengeKey]string)
+	for _, c := range challenges {
+		addChallengeKey(ids, c.Genre, c.Name, c.ID)
+	}
+	tx := db.Begin()
+	if _, err := bundle.save(tx, challenge, ids, editorID); err != nil {
+		tx.Rollback()
+		return impacts, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return impacts, err
+	}
+	invalidateScoreCaches()
+	return impacts, nil
+}
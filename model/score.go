@@ -0,0 +1,279 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//Scoring Modes of a Challenge
+const (
+	ScoringStatic = "static"
+	ScoringLinear = "linear"
+	ScoringLog    = "log"
+)
+
+//ErrInvalidScoring an Error due to Invalid Scoring Parameters
+var ErrInvalidScoring = fmt.Errorf("invalid scoring parameters")
+
+func validateScoring(scoringMode string, score int, minimumScore int, decay int) error {
+	switch scoringMode {
+	case "", ScoringStatic:
+		return nil
+	case ScoringLinear, ScoringLog:
+		if minimumScore < 0 || score < minimumScore || decay <= 0 {
+			return ErrInvalidScoring
+		}
+		return nil
+	}
+	return ErrInvalidScoring
+}
+
+func validateBonuses(bonuses []int) error {
+	for _, bonus := range bonuses {
+		if bonus < 0 {
+			return ErrInvalidScoring
+		}
+	}
+	return nil
+}
+
+func joinBonuses(bonuses []int) string {
+	strs := make([]string, len(bonuses))
+	for i, bonus := range bonuses {
+		strs[i] = strconv.Itoa(bonus)
+	}
+	return strings.Join(strs, ",")
+}
+
+//BonusPercents Get the Bonus Percentages for the Early Solvers of the Challenge
+func (challenge *Challenge) BonusPercents() []int {
+	bonuses := make([]int, 0)
+	if challenge.Bonuses == "" {
+		return bonuses
+	}
+	for _, str := range strings.Split(challenge.Bonuses, ",") {
+		bonus, _ := strconv.Atoi(str)
+		bonuses = append(bonuses, bonus)
+	}
+	return bonuses
+}
+
+//BonusAt Calculate the Bonus for the User who Solved the Challenge in the Given Rank (0-origin)
+func (challenge *Challenge) BonusAt(rank int) int {
+	bonuses := challenge.BonusPercents()
+	if rank < 0 || len(bonuses) <= rank {
+		return 0
+	}
+	return challenge.Score * bonuses[rank] / 100
+}
+
+//SolveRank Get the Rank (0-origin) in which the User Solved the Challenge, or -1 if they have Not Solved it
+func (challenge *Challenge) SolveRank(userID string) int {
+	for i, solve := range challenge.Solves {
+		if solve.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+//ScoreAt Calculate the Value of the Challenge when it has been Solved by the Given Number of Users, which Starts Decaying from the Second Solve
+func (challenge *Challenge) ScoreAt(solves int) int {
+	decayed := solves - 1
+	if challenge.Decay <= 0 || decayed <= 0 {
+		return challenge.Score
+	}
+	diff := float64(challenge.Score - challenge.MinimumScore)
+	switch challenge.ScoringMode {
+	case ScoringLinear:
+		score := challenge.Score - int(diff*float64(decayed)/float64(challenge.Decay))
+		if score < challenge.MinimumScore {
+			return challenge.MinimumScore
+		}
+		return score
+	case ScoringLog:
+		score := challenge.Score - int(diff*math.Log1p(float64(decayed))/math.Log1p(float64(challenge.Decay)))
+		if score < challenge.MinimumScore {
+			return challenge.MinimumScore
+		}
+		return score
+	}
+	return challenge.Score
+}
+
+//CurrentScore Calculate the Current Value of the Challenge
+func (challenge *Challenge) CurrentScore() int {
+	return challenge.ScoreAt(len(challenge.WhoSolved))
+}
+
+//FlagScoreAt Calculate the Value of the Flag when the Challenge has been Solved by the Given Number of Users
+func (challenge *Challenge) FlagScoreAt(_flag *Flag, solves int) int {
+	if challenge.Score == 0 {
+		return _flag.Score
+	}
+	return _flag.Score * challenge.ScoreAt(solves) / challenge.Score
+}
+
+//IsDynamic Whether the Value of the Challenge Changes along with its Solves
+func (challenge *Challenge) IsDynamic() bool {
+	return challenge.ScoringMode == ScoringLinear || challenge.ScoringMode == ScoringLog
+}
+
+//invalidateScoreCaches Discard the Cached Values Derived from the Scores
+func invalidateScoreCaches() {
+	invalidateTimelines()
+	invalidateStandings()
+}
+
+//foundFlagScore Calculate the Current Value of the FoundFlag of the Challenge
+func (challenge *Challenge) foundFlagScore(foundFlag *FoundFlag) int {
+	for _, _flag := range challenge.Flags {
+		if _flag.ID == foundFlag.FlagID {
+			return ApplyPenalty(challenge.FlagScoreAt(_flag, len(challenge.Solves)), foundFlag.PenaltyPercent)
+		}
+	}
+	return foundFlag.Score
+}
+
+//calcUserScore Calculate the User's Score from their FoundFlag and Solve Records
+func calcUserScore(tx *gorm.DB, userID string) (int, error) {
+	return calcScore(tx, userID, []string{userID}, func(challenge *Challenge) int { return challenge.SolveRank(userID) })
+}
+
+//calcScore Calculate the Score of the Owner, which is the Users Together, from their FoundFlag Records and the Owner's Awards, Adding the Bonuses for their Solve Ranks and Deducting the Costs of their Hints
+func calcScore(tx *gorm.DB, ownerID string, userIDs []string, rankOf func(*Challenge) int) (int, error) {
+	genreScores, err := calcGenreScores(tx, ownerID, userIDs, rankOf)
+	if err != nil {
+		return 0, err
+	}
+	sum := 0
+	for _, score := range genreScores {
+		sum += score
+	}
+
+	costs, err := getHintCosts(tx, userIDs, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	awards, err := getAwards(tx, ownerID, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	for _, award := range awards {
+		sum -= award.Cost
+	}
+	return sum - sumHintCosts(costs), nil
+}
+
+//calcGenreScores Calculate the Scores of the Owner, which is the Users Together, in Each Genre from their FoundFlag Records and the Owner's Awards, Adding the Bonuses for their Solve Ranks
+func calcGenreScores(tx *gorm.DB, ownerID string, userIDs []string, rankOf func(*Challenge) int) (map[string]int, error) {
+	foundFlags := make([]*FoundFlag, 0)
+	if err := tx.Joins("JOIN user_found_flags ON user_found_flags.found_flag_id = found_flags.id").Where("user_found_flags.user_id IN (?)", userIDs).Find(&foundFlags).Error; err != nil {
+		return nil, err
+	}
+
+	challengeIDs := make([]string, 0)
+	for _, foundFlag := range foundFlags {
+		challengeIDs = append(challengeIDs, foundFlag.ChallengeID)
+	}
+	challenges := make(map[string]*Challenge)
+	if len(challengeIDs) > 0 {
+		found := make([]*Challenge, 0)
+		if err := tx.Unscoped().Where("id IN (?)", challengeIDs).Preload("Flags").Preload("Solves", orderSolves).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, challenge := range found {
+			challenges[challenge.ID] = challenge
+		}
+	}
+
+	best := make(map[string]int)
+	for _, foundFlag := range foundFlags {
+		score := foundFlag.Score
+		if challenge, ok := challenges[foundFlag.ChallengeID]; ok {
+			score = challenge.foundFlagScore(foundFlag)
+		}
+		if best[foundFlag.ChallengeID] < score {
+			best[foundFlag.ChallengeID] = score
+		}
+	}
+
+	genreScores := make(map[string]int)
+	for id, score := range best {
+		if challenge, ok := challenges[id]; ok {
+			genreScores[challenge.Genre] += score
+		} else {
+			genreScores[""] += score
+		}
+	}
+	for _, challenge := range challenges {
+		genreScores[challenge.Genre] += challenge.BonusAt(rankOf(challenge))
+	}
+
+	awards, err := getAwards(tx, ownerID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for _, award := range awards {
+		genreScores[award.Genre] += award.Score
+	}
+	return genreScores, nil
+}
+
+//recalcScoresOfChallenge Recalculate the Scores of All Users and Teams who have Found any Flag or Opened any Hint of the Challenge
+func recalcScoresOfChallenge(tx *gorm.DB, challengeID string) error {
+	userIDs := make([]string, 0)
+	if err := tx.Table("user_found_flags").Joins("JOIN found_flags ON found_flags.id = user_found_flags.found_flag_id").Where("found_flags.challenge_id = ?", challengeID).Pluck("DISTINCT user_found_flags.user_id", &userIDs).Error; err != nil {
+		return err
+	}
+	openerIDs := make([]string, 0)
+	if err := tx.Table("user_opened_hints").Joins("JOIN hints ON hints.id = user_opened_hints.hint_id").Where("hints.challenge_id = ?", challengeID).Pluck("DISTINCT user_opened_hints.user_id", &openerIDs).Error; err != nil {
+		return err
+	}
+	return recalcScoresOfUsers(tx, mergeIDs(userIDs, openerIDs))
+}
+
+//recalcScoresOfUsers Recalculate the Scores of the Users and their Teams
+func recalcScoresOfUsers(tx *gorm.DB, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	for _, userID := range userIDs {
+		score, err := calcUserScore(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&User{}).Where(&User{ID: userID}).UpdateColumn("score", score).Error; err != nil {
+			return err
+		}
+	}
+
+	teamIDs := make([]string, 0)
+	if err := tx.Model(&User{}).Where("id IN (?) AND team_id <> ''", userIDs).Pluck("DISTINCT team_id", &teamIDs).Error; err != nil {
+		return err
+	}
+	for _, teamID := range teamIDs {
+		if err := recalcTeamScore(tx, teamID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//mergeIDs Merge the Lists of IDs without Duplicates
+func mergeIDs(a []string, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	ids := make([]string, 0, len(a)+len(b))
+	for _, id := range append(append([]string{}, a...), b...) {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
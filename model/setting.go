@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//Setting a Setting Record which can be Changed while the Server is Running
+type Setting struct {
+	Key       string `gorm:"primary_key"`
+	Value     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func getSetting(key string) (string, error) {
+	setting := &Setting{}
+	if err := db.Where(&Setting{Key: key}).First(setting).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return setting.Value, nil
+}
+
+func setSetting(key string, value string) error {
+	return db.Save(&Setting{Key: key, Value: value}).Error
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+//Standing a User's or Team's Standing Calculated from the Records
+type Standing struct {
+	Score       int
+	GenreScores map[string]int
+	Solved      int
+	ReachedAt   time.Time
+}
+
+var (
+	standingsMu    sync.Mutex
+	standingsCache = make(map[bool]map[string]*Standing)
+)
+
+//invalidateStandings Discard the Cached Standings
+func invalidateStandings() {
+	standingsMu.Lock()
+	standingsCache = make(map[bool]map[string]*Standing)
+	standingsMu.Unlock()
+}
+
+type userFoundFlag struct {
+	UserID         string
+	FlagID         string
+	ChallengeID    string
+	Score          int
+	PenaltyPercent int
+	CreatedAt      time.Time
+}
+
+//GetStandings Get the Standings of All Users and Teams Keyed by their IDs, as of the Freeze if frozen is true
+func GetStandings(frozen bool) (map[string]*Standing, error) {
+	standingsMu.Lock()
+	defer standingsMu.Unlock()
+	if standings, ok := standingsCache[frozen]; ok {
+		return standings, nil
+	}
+
+	until := time.Time{}
+	if frozen {
+		until = FreezeTime()
+	}
+	standings, err := calcStandings(until)
+	if err != nil {
+		return nil, err
+	}
+	standingsCache[frozen] = standings
+	return standings, nil
+}
+
+//calcStandings Calculate the Standings of All Users and Teams from the Records before the Time (Zero means Now)
+func calcStandings(until time.Time) (map[string]*Standing, error) {
+	isBefore := func(t time.Time) bool { return until.IsZero() || t.Before(until) }
+
+	challenges := make([]*Challenge, 0)
+	if err := db.Unscoped().Preload("Flags").Preload("Solves", orderSolves).Find(&challenges).Error; err != nil {
+		return nil, err
+	}
+	challengeMap := make(map[string]*Challenge, len(challenges))
+	for _, challenge := range challenges {
+		solves := make([]*Solve, 0, len(challenge.Solves))
+		for _, solve := range challenge.Solves {
+			if isBefore(solve.CreatedAt) {
+				solves = append(solves, solve)
+			}
+		}
+		challenge.Solves = solves
+		challengeMap[challenge.ID] = challenge
+	}
+
+	users := make([]*User, 0)
+	if err := db.Select("id, team_id, is_author").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	teamOf := make(map[string]string, len(users))
+	for _, user := range users {
+		if user.TeamID != "" && !user.IsAuthor {
+			teamOf[user.ID] = user.TeamID
+		}
+	}
+
+	query := db.Table("found_flags").Select("user_found_flags.user_id, found_flags.flag_id, found_flags.challenge_id, found_flags.score, found_flags.penalty_percent, found_flags.created_at").Joins("JOIN user_found_flags ON user_found_flags.found_flag_id = found_flags.id").Where("found_flags.deleted_at IS NULL")
+	if !until.IsZero() {
+		query = query.Where("found_flags.created_at < ?", until)
+	}
+	foundFlags := make([]*userFoundFlag, 0)
+	if err := query.Scan(&foundFlags).Error; err != nil {
+		return nil, err
+	}
+	sort.SliceStable(foundFlags, func(i, j int) bool { return foundFlags[i].CreatedAt.Before(foundFlags[j].CreatedAt) })
+
+	standings := make(map[string]*Standing)
+	standingOf := func(id string) *Standing {
+		standing, ok := standings[id]
+		if !ok {
+			standing = &Standing{GenreScores: make(map[string]int)}
+			standings[id] = standing
+		}
+		return standing
+	}
+
+	best := make(map[string]map[string]int)
+	for _, foundFlag := range foundFlags {
+		score, genre := foundFlag.Score, ""
+		if challenge, ok := challengeMap[foundFlag.ChallengeID]; ok {
+			score = challenge.foundFlagScore(&FoundFlag{FlagID: foundFlag.FlagID, Score: foundFlag.Score, PenaltyPercent: foundFlag.PenaltyPercent})
+			genre = challenge.Genre
+		}
+		ids := []string{foundFlag.UserID}
+		if teamID, ok := teamOf[foundFlag.UserID]; ok {
+			ids = append(ids, teamID)
+		}
+		for _, id := range ids {
+			if best[id] == nil {
+				best[id] = make(map[string]int)
+			}
+			if best[id][foundFlag.ChallengeID] < score {
+				standing := standingOf(id)
+				standing.Score += score - best[id][foundFlag.ChallengeID]
+				standing.GenreScores[genre] += score - best[id][foundFlag.ChallengeID]
+				standing.ReachedAt = foundFlag.CreatedAt
+				best[id][foundFlag.ChallengeID] = score
+			}
+		}
+	}
+
+	costs, err := getHintCosts(db, nil, until)
+	if err != nil {
+		return nil, err
+	}
+	costsOfTeam := make(map[string][]*hintCost)
+	for _, cost := range costs {
+		standingOf(cost.UserID).Score -= cost.Cost
+		if teamID, ok := teamOf[cost.UserID]; ok {
+			costsOfTeam[teamID] = append(costsOfTeam[teamID], cost)
+		}
+	}
+	for teamID, costs := range costsOfTeam {
+		standingOf(teamID).Score -= sumHintCosts(costs)
+	}
+
+	awards, err := getAwards(db, "", until)
+	if err != nil {
+		return nil, err
+	}
+	for _, award := range awards {
+		standing := standingOf(award.OwnerID)
+		standing.Score += award.Score - award.Cost
+		standing.GenreScores[award.Genre] += award.Score
+		if award.Solved {
+			standing.Solved++
+		}
+		if award.Score > 0 && standing.ReachedAt.Before(award.CreatedAt) {
+			standing.ReachedAt = award.CreatedAt
+		}
+	}
+
+	for _, challenge := range challenges {
+		solvedTeams := make(map[string]struct{})
+		for rank, solve := range challenge.Solves {
+			standing := standingOf(solve.UserID)
+			standing.Solved++
+			standing.Score += challenge.BonusAt(rank)
+			standing.GenreScores[challenge.Genre] += challenge.BonusAt(rank)
+
+			teamID, ok := teamOf[solve.UserID]
+			if !ok {
+				continue
+			}
+			if _, solved := solvedTeams[teamID]; solved {
+				continue
+			}
+			solvedTeams[teamID] = struct{}{}
+			bonus := challenge.BonusAt(challenge.teamSolveRank(teamOf, teamID))
+			teamStanding := standingOf(teamID)
+			teamStanding.Solved++
+			teamStanding.Score += bonus
+			teamStanding.GenreScores[challenge.Genre] += bonus
+		}
+	}
+
+	return standings, nil
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//Submission a Record of a Flag Submission
+type Submission struct {
+	ID          int `gorm:"primary_key"`
+	UserID      string
+	User        *User `gorm:"foreignkey:UserID"`
+	ChallengeID string
+	Challenge   *Challenge `gorm:"foreignkey:ChallengeID"`
+	Flag        string
+	IsCorrect   bool
+	FlagID      string
+	Score       int
+	IP          string
+	UserAgent   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+}
+
+//GetSubmissions Get the Submission Records Matching the Conditions (Empty Conditions are Ignored)
+func GetSubmissions(userID string, challengeID string, isCorrect *bool, since time.Time, until time.Time) ([]*Submission, error) {
+	submissions := make([]*Submission, 0)
+	query := db.Where(&Submission{UserID: userID, ChallengeID: challengeID})
+	if isCorrect != nil {
+		query = query.Where("is_correct = ?", *isCorrect)
+	}
+	if !since.IsZero() {
+		query = query.Where("created_at >= ?", since)
+	}
+	if !until.IsZero() {
+		query = query.Where("created_at < ?", until)
+	}
+	if err := query.Preload("User").Preload("Challenge", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Order("created_at desc").Find(&submissions).Error; err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
@@ -0,0 +1,281 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/satori/go.uuid"
+)
+
+//Team a Team Record
+type Team struct {
+	ID         string `gorm:"primary_key"`
+	Name       string
+	InviteCode string
+	LeaderID   string
+	Leader     *User   `gorm:"foreignkey:LeaderID"`
+	Members    []*User `gorm:"foreignkey:TeamID"`
+	Score      int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+}
+
+//ErrTeamNotFound an Error due to the Team Not Found
+var ErrTeamNotFound = gorm.ErrRecordNotFound
+
+//ErrAlreadyInTeam an Error due to the User Already Belonging to a Team
+var ErrAlreadyInTeam = fmt.Errorf("already in a team")
+
+//ErrNotInTeam an Error due to the User Not Belonging to the Team
+var ErrNotInTeam = fmt.Errorf("not in the team")
+
+//ErrWrongInviteCode an Error due to a Wrong Invite Code
+var ErrWrongInviteCode = fmt.Errorf("wrong invite code")
+
+//ErrTeamFull an Error due to the Team Having No Room
+var ErrTeamFull = fmt.Errorf("the team is full")
+
+//GetTeams Get All Team Records, Sorted by their Scores as of the Freeze if frozen is true
+func GetTeams(frozen bool) ([]*Team, error) {
+	teams := make([]*Team, 0)
+	if !frozen {
+		if err := db.Preload("Leader").Preload("Members").Order("score desc").Find(&teams).Error; err != nil {
+			return nil, err
+		}
+		return teams, nil
+	}
+
+	//Ties are Left in the Order of the IDs so that the Live Scores do Not Leak
+	if err := db.Preload("Leader").Preload("Members").Order("id").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+
+	standings, err := GetStandings(true)
+	if err != nil {
+		return nil, err
+	}
+	frozenScore := func(team *Team) int {
+		if standing, ok := standings[team.ID]; ok {
+			return standing.Score
+		}
+		return 0
+	}
+	sort.SliceStable(teams, func(i, j int) bool { return frozenScore(teams[i]) > frozenScore(teams[j]) })
+	return teams, nil
+}
+
+//GetTeamByID Get the Team Record by its ID
+func GetTeamByID(id string) (*Team, error) {
+	team := &Team{}
+	if err := db.Where(&Team{ID: id}).Preload("Leader").Preload("Members").First(team).Error; err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+//NewTeam Make a New Team Record Led by the User
+func NewTeam(name string, leader *User) (*Team, error) {
+	if leader.TeamID != "" {
+		return nil, ErrAlreadyInTeam
+	}
+
+	tx := db.Begin()
+
+	team := &Team{
+		ID:         uuid.NewV4().String(),
+		Name:       name,
+		InviteCode: strings.Replace(uuid.NewV4().String(), "-", "", -1)[:12],
+		LeaderID:   leader.ID,
+		Leader:     leader,
+	}
+	if err := tx.Create(team).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	leader.TeamID = team.ID
+	if err := tx.Save(leader).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	team.Members = []*User{leader}
+
+	if err := recalcTeamScore(tx, team.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	invalidateScoreCaches()
+	return team, nil
+}
+
+//Join Make the User Join the Team with the Invite Code
+func (team *Team) Join(user *User, inviteCode string) error {
+	if user.TeamID != "" {
+		return ErrAlreadyInTeam
+	}
+	if team.InviteCode != inviteCode {
+		return ErrWrongInviteCode
+	}
+	if limit := TeamSizeLimit(); limit > 0 && len(team.Members) >= limit {
+		return ErrTeamFull
+	}
+
+	tx := db.Begin()
+
+	user.TeamID = team.ID
+	if err := tx.Save(user).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	team.Members = append(team.Members, user)
+
+	if err := recalcTeamScore(tx, team.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	invalidateScoreCaches()
+	return nil
+}
+
+//Leave Make the User Leave the Team, Passing the Leadership or Deleting the Team if Needed
+func (team *Team) Leave(user *User) error {
+	if user.TeamID != team.ID {
+		return ErrNotInTeam
+	}
+
+	tx := db.Begin()
+
+	user.TeamID = ""
+	if err := tx.Save(user).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	members := make([]*User, 0, len(team.Members))
+	for _, member := range team.Members {
+		if member.ID != user.ID {
+			members = append(members, member)
+		}
+	}
+	team.Members = members
+
+	if len(team.Members) == 0 {
+		if err := tx.Delete(team).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+		invalidateScoreCaches()
+		return nil
+	}
+
+	if team.LeaderID == user.ID {
+		team.LeaderID, team.Leader = team.Members[0].ID, team.Members[0]
+		if err := tx.Save(team).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := recalcTeamScore(tx, team.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	invalidateScoreCaches()
+	return nil
+}
+
+//Teammates Get the Users whose Solves, Hints and Flags are Shared with the User, including the User, Loading the Others' Records Only when Called
+func (user *User) Teammates() ([]*User, error) {
+	if !IsTeamMode() || user.TeamID == "" {
+		return []*User{user}, nil
+	}
+	others := make([]*User, 0)
+	if err := db.Where("team_id = ? AND id <> ?", user.TeamID, user.ID).Preload("SolvedChallenges").Preload("OpenedHints").Preload("FoundFlags").Find(&others).Error; err != nil {
+		return nil, err
+	}
+	return append([]*User{user}, others...), nil
+}
+
+//teammateIDs Get the IDs of the Users whose Solves, Hints and Flags are Shared with the User, including the User
+func teammateIDs(tx *gorm.DB, user *User) ([]string, error) {
+	if !IsTeamMode() || user.TeamID == "" {
+		return []string{user.ID}, nil
+	}
+	userIDs := make([]string, 0)
+	if err := tx.Model(&User{}).Where(&User{TeamID: user.TeamID}).Pluck("id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+//teamSolveRank Get the Rank (0-origin) in which the Team Solved the Challenge, Regarding Users without Teams as Teams of their Own
+func (challenge *Challenge) teamSolveRank(teamOf map[string]string, teamID string) int {
+	seen := make(map[string]struct{})
+	for _, solve := range challenge.Solves {
+		solverTeamID, ok := teamOf[solve.UserID]
+		if !ok {
+			solverTeamID = "user:" + solve.UserID
+		}
+		if solverTeamID == teamID {
+			return len(seen)
+		}
+		seen[solverTeamID] = struct{}{}
+	}
+	return -1
+}
+
+//teamScorers Get the IDs of the Team's Members Counted in its Score, who are Not Authors, and the Function to Get the Team's Solve Rank
+func teamScorers(tx *gorm.DB, teamID string) ([]string, func(*Challenge) int, error) {
+	users := make([]*User, 0)
+	if err := tx.Select("id, team_id").Where("team_id <> '' AND is_author = ?", false).Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+	teamOf := make(map[string]string, len(users))
+	memberIDs := make([]string, 0)
+	for _, user := range users {
+		teamOf[user.ID] = user.TeamID
+		if user.TeamID == teamID {
+			memberIDs = append(memberIDs, user.ID)
+		}
+	}
+	return memberIDs, func(challenge *Challenge) int { return challenge.teamSolveRank(teamOf, teamID) }, nil
+}
+
+//calcTeamScore Calculate the Team's Score from its Members' FoundFlag and Solve Records
+func calcTeamScore(tx *gorm.DB, teamID string) (int, error) {
+	memberIDs, rankOf, err := teamScorers(tx, teamID)
+	if err != nil {
+		return 0, err
+	}
+	if len(memberIDs) == 0 {
+		return 0, nil
+	}
+	return calcScore(tx, teamID, memberIDs, rankOf)
+}
+
+//recalcTeamScore Recalculate the Team's Score and Store it
+func recalcTeamScore(tx *gorm.DB, teamID string) error {
+	score, err := calcTeamScore(tx, teamID)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&Team{}).Where(&Team{ID: teamID}).UpdateColumn("score", score).Error
+}
@@ -0,0 +1,157 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+//TimelinePoint a Point of a Score Timeline
+type TimelinePoint struct {
+	Time  time.Time
+	Score int
+}
+
+//Timeline a Score Timeline of a User
+type Timeline struct {
+	User   *User
+	Points []*TimelinePoint
+}
+
+type timelineKey struct {
+	top    int
+	frozen bool
+}
+
+var (
+	timelineCacheMu sync.Mutex
+	timelineCache   = make(map[timelineKey][]*Timeline)
+)
+
+//invalidateTimelines Discard the Cached Score Timelines
+func invalidateTimelines() {
+	timelineCacheMu.Lock()
+	timelineCache = make(map[timelineKey][]*Timeline)
+	timelineCacheMu.Unlock()
+}
+
+type timelineEvent struct {
+	time        time.Time
+	challengeID string
+	score       int
+	bonus       int
+}
+
+//GetScoreTimelines Get the Score Timelines of the Top Users except Authors, who are Chosen as of the Freeze if frozen is true
+func GetScoreTimelines(top int, frozen bool) ([]*Timeline, error) {
+	timelineCacheMu.Lock()
+	defer timelineCacheMu.Unlock()
+	key := timelineKey{top: top, frozen: frozen}
+	if timelines, ok := timelineCache[key]; ok {
+		return timelines, nil
+	}
+
+	users := make([]*User, 0)
+	if frozen {
+		entries, err := GetRanking(true, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if len(users) >= top {
+				break
+			}
+			users = append(users, entry.User)
+		}
+	} else if err := db.Where("is_author = ?", false).Order("score desc").Order("last_solved_time").Limit(top).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	challenges := make([]*Challenge, 0)
+	if err := db.Unscoped().Preload("Flags").Preload("Solves", orderSolves).Find(&challenges).Error; err != nil {
+		return nil, err
+	}
+	challengeMap := make(map[string]*Challenge, len(challenges))
+	for _, challenge := range challenges {
+		challengeMap[challenge.ID] = challenge
+	}
+
+	start := StartTime()
+	timelines := make([]*Timeline, len(users))
+	for i, user := range users {
+		foundFlags := make([]*FoundFlag, 0)
+		if err := db.Joins("JOIN user_found_flags ON user_found_flags.found_flag_id = found_flags.id").Where("user_found_flags.user_id = ?", user.ID).Find(&foundFlags).Error; err != nil {
+			return nil, err
+		}
+
+		events := make([]*timelineEvent, 0, len(foundFlags))
+		for _, foundFlag := range foundFlags {
+			score := foundFlag.Score
+			if challenge, ok := challengeMap[foundFlag.ChallengeID]; ok {
+				score = challenge.foundFlagScore(foundFlag)
+			}
+			events = append(events, &timelineEvent{
+				time:        foundFlag.CreatedAt,
+				challengeID: foundFlag.ChallengeID,
+				score:       score,
+			})
+		}
+		for _, challenge := range challenges {
+			if rank := challenge.SolveRank(user.ID); rank >= 0 {
+				events = append(events, &timelineEvent{
+					time:  challenge.Solves[rank].CreatedAt,
+					bonus: challenge.BonusAt(rank),
+				})
+			}
+		}
+		costs, err := getHintCosts(db, []string{user.ID}, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		for _, cost := range costs {
+			event := &timelineEvent{
+				time:  start,
+				bonus: -cost.Cost,
+			}
+			if cost.CreatedAt != nil {
+				event.time = *cost.CreatedAt
+			}
+			events = append(events, event)
+		}
+		awards, err := getAwards(db, user.ID, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		for _, award := range awards {
+			events = append(events, &timelineEvent{
+				time:  award.CreatedAt,
+				bonus: award.Score - award.Cost,
+			})
+		}
+		sort.SliceStable(events, func(i, j int) bool { return events[i].time.Before(events[j].time) })
+
+		points := []*TimelinePoint{{Time: start, Score: 0}}
+		best := make(map[string]int)
+		total := 0
+		for _, event := range events {
+			if event.challengeID != "" && best[event.challengeID] < event.score {
+				total += event.score - best[event.challengeID]
+				best[event.challengeID] = event.score
+			}
+			total += event.bonus
+			if last := points[len(points)-1]; last.Time.Equal(event.time) {
+				last.Score = total
+			} else {
+				points = append(points, &TimelinePoint{Time: event.time, Score: total})
+			}
+		}
+
+		timelines[i] = &Timeline{
+			User:   user,
+			Points: points,
+		}
+	}
+
+	timelineCache[key] = timelines
+	return timelines, nil
+}
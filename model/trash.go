@@ -0,0 +1,304 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+//Award a Record of the Points Awarded to a User or a Team for a Challenge, which are Kept when the Challenge is Purged without Revoking them
+type Award struct {
+	ID          int `gorm:"primary_key"`
+	OwnerID     string
+	ChallengeID string
+	Genre       string
+	Score       int
+	Cost        int
+	Solved      bool
+	CreatedAt   time.Time
+}
+
+//keepAwards Record the Points Awarded to the Users and the Teams for the Challenge, including the Bonuses and the Costs of its Hints, as Awards
+func keepAwards(tx *gorm.DB, challengeID string) error {
+	challenge := &Challenge{}
+	if err := tx.Unscoped().Where(&Challenge{ID: challengeID}).Preload("Flags").Preload("Solves", orderSolves).First(challenge).Error; err != nil {
+		return err
+	}
+
+	users := make([]*User, 0)
+	if err := tx.Select("id, team_id, is_author").Find(&users).Error; err != nil {
+		return err
+	}
+	teamOf := make(map[string]string, len(users))
+	for _, user := range users {
+		if user.TeamID != "" && !user.IsAuthor {
+			teamOf[user.ID] = user.TeamID
+		}
+	}
+
+	awards := make(map[string]*Award)
+	awardOf := func(id string) *Award {
+		award, ok := awards[id]
+		if !ok {
+			award = &Award{OwnerID: id, ChallengeID: challenge.ID, Genre: challenge.Genre}
+			awards[id] = award
+		}
+		return award
+	}
+
+	foundFlags := make([]*userFoundFlag, 0)
+	if err := tx.Table("found_flags").Select("user_found_flags.user_id, found_flags.flag_id, found_flags.challenge_id, found_flags.score, found_flags.penalty_percent, found_flags.created_at").Joins("JOIN user_found_flags ON user_found_flags.found_flag_id = found_flags.id").Where("found_flags.challenge_id = ? AND found_flags.deleted_at IS NULL", challenge.ID).Scan(&foundFlags).Error; err != nil {
+		return err
+	}
+	for _, foundFlag := range foundFlags {
+		score := challenge.foundFlagScore(&FoundFlag{FlagID: foundFlag.FlagID, Score: foundFlag.Score, PenaltyPercent: foundFlag.PenaltyPercent})
+		ids := []string{foundFlag.UserID}
+		if teamID, ok := teamOf[foundFlag.UserID]; ok {
+			ids = append(ids, teamID)
+		}
+		for _, id := range ids {
+			award := awardOf(id)
+			if award.Score < score {
+				award.Score = score
+			}
+			if award.CreatedAt.Before(foundFlag.CreatedAt) {
+				award.CreatedAt = foundFlag.CreatedAt
+			}
+		}
+	}
+
+	solvedTeams := make(map[string]struct{})
+	for rank, solve := range challenge.Solves {
+		award := awardOf(solve.UserID)
+		award.Score += challenge.BonusAt(rank)
+		award.Solved = true
+		teamID, ok := teamOf[solve.UserID]
+		if !ok {
+			continue
+		}
+		if _, solved := solvedTeams[teamID]; solved {
+			continue
+		}
+		solvedTeams[teamID] = struct{}{}
+		teamAward := awardOf(teamID)
+		teamAward.Score += challenge.BonusAt(challenge.teamSolveRank(teamOf, teamID))
+		teamAward.Solved = true
+	}
+
+	hintIDs := make([]string, 0)
+	if err := tx.Model(&Hint{}).Where(&Hint{ChallengeID: challenge.ID}).Pluck("id", &hintIDs).Error; err != nil {
+		return err
+	}
+	if len(hintIDs) > 0 {
+		costs := make([]*hintCost, 0)
+		if err := tx.Table("user_opened_hints").Select("user_opened_hints.user_id, user_opened_hints.hint_id, hints.cost, user_opened_hints.created_at").Joins("JOIN hints ON hints.id = user_opened_hints.hint_id").Where("hints.cost > 0 AND hints.id IN (?)", hintIDs).Scan(&costs).Error; err != nil {
+			return err
+		}
+		costsOf := make(map[string][]*hintCost)
+		for _, cost := range costs {
+			costsOf[cost.UserID] = append(costsOf[cost.UserID], cost)
+			if teamID, ok := teamOf[cost.UserID]; ok {
+				costsOf[teamID] = append(costsOf[teamID], cost)
+			}
+		}
+		for id, costs := range costsOf {
+			award := awardOf(id)
+			award.Cost = sumHintCosts(costs)
+			for _, cost := range costs {
+				if cost.CreatedAt != nil && award.CreatedAt.Before(*cost.CreatedAt) {
+					award.CreatedAt = *cost.CreatedAt
+				}
+			}
+		}
+	}
+
+	for _, award := range awards {
+		if award.CreatedAt.IsZero() {
+			award.CreatedAt = StartTime()
+		}
+		if err := tx.Create(award).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//getAwards Get the Awards of the Owner, or of All Owners if ownerID is Empty, before the Time (Zero means Now)
+func getAwards(tx *gorm.DB, ownerID string, until time.Time) ([]*Award, error) {
+	query := tx.Model(&Award{})
+	if ownerID != "" {
+		query = query.Where(&Award{OwnerID: ownerID})
+	}
+	if !until.IsZero() {
+		query = query.Where("created_at < ?", until)
+	}
+	awards := make([]*Award, 0)
+	if err := query.Order("created_at").Find(&awards).Error; err != nil {
+		return nil, err
+	}
+	return awards, nil
+}
+
+//GetTrashedChallenges Get All Challenge Records which have been Deleted but Not Purged
+func GetTrashedChallenges() ([]*Challenge, error) {
+	challenges := make([]*Challenge, 0)
+	if err := db.Unscoped().Where("deleted_at IS NOT NULL").Preload("Author").Preload("Solves", orderSolves).Order("deleted_at desc").Find(&challenges).Error; err != nil {
+		return nil, err
+	}
+	return challenges, nil
+}
+
+//GetTrashedChallengeByID Get the Challenge Record which has been Deleted but Not Purged
+func GetTrashedChallengeByID(id string) (*Challenge, error) {
+	challenge := &Challenge{}
+	if err := db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Preload("Author").Preload("Solves", orderSolves).First(challenge).Error; err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+//Restore Restore the Deleted Challenge Record
+func (challenge *Challenge) Restore(actorID string) error {
+	tx := db.Begin()
+
+	if err := tx.Unscoped().Model(challenge).UpdateColumn("deleted_at", nil).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	challenge.DeletedAt = nil
+
+	if err := recordAudit(tx, actorID, AuditRestoreChallenge, challenge.ID, challenge.Name); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	invalidateScoreCaches()
+	return nil
+}
+
+//Purge Delete the Deleted Challenge Record and the Records Belonging to it Permanently, and also the Points Awarded for it if revoke is true, or Keep them as Awards Otherwise
+func (challenge *Challenge) Purge(revoke bool, actorID string) error {
+	tx := db.Begin()
+
+	if !revoke {
+		if err := keepAwards(tx, challenge.ID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	userIDs := make([]string, 0)
+	if err := tx.Table("user_found_flags").Joins("JOIN found_flags ON found_flags.id = user_found_flags.found_flag_id").Where("found_flags.challenge_id = ?", challenge.ID).Pluck("DISTINCT user_found_flags.user_id", &userIDs).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	foundFlagIDs := make([]int, 0)
+	if err := tx.Unscoped().Model(&FoundFlag{}).Where(&FoundFlag{ChallengeID: challenge.ID}).Pluck("id", &foundFlagIDs).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(foundFlagIDs) > 0 {
+		if err := tx.Exec("DELETE FROM user_found_flags WHERE found_flag_id IN (?)", foundFlagIDs).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Unscoped().Where(&FoundFlag{ChallengeID: challenge.ID}).Delete(&FoundFlag{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where(&Solve{ChallengeID: challenge.ID}).Delete(&Solve{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	hintIDs := make([]string, 0)
+	if err := tx.Unscoped().Model(&Hint{}).Where(&Hint{ChallengeID: challenge.ID}).Pluck("id", &hintIDs).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(hintIDs) > 0 {
+		openerIDs := make([]string, 0)
+		if err := tx.Model(&HintOpen{}).Where("hint_id IN (?)", hintIDs).Pluck("DISTINCT user_id", &openerIDs).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		userIDs = mergeIDs(userIDs, openerIDs)
+		if err := tx.Where("hint_id IN (?)", hintIDs).Delete(&HintOpen{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for _, table := range []string{"user_challenged_challenges", "user_opened_challenges"} {
+		if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE challenge_id = ?", table), challenge.ID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for _, value := range []interface{}{&Hint{}, &Flag{}, &Vote{}, &Revision{}} {
+		if err := tx.Unscoped().Where("challenge_id = ?", challenge.ID).Delete(value).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Where("challenge_id = ? OR required_challenge_id = ?", challenge.ID, challenge.ID).Delete(&Prerequisite{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&Question{}).Where(&Question{ChallengeID: challenge.ID}).UpdateColumn("challenge_id", "").Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&Announcement{}).Where(&Announcement{ChallengeID: challenge.ID}).UpdateColumn("challenge_id", "").Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&User{}).Where(&User{LastSeenChallengeID: challenge.ID}).UpdateColumn("last_seen_challenge_id", "").Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&User{}).Where(&User{LastSolvedChallengeID: challenge.ID}).UpdateColumn("last_solved_challenge_id", "").Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	attachments := make([]*Attachment, 0)
+	if err := tx.Where(&Attachment{ChallengeID: challenge.ID}).Find(&attachments).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where(&Attachment{ChallengeID: challenge.ID}).Delete(&Attachment{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Unscoped().Delete(challenge).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := recalcScoresOfUsers(tx, userIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	detail := fmt.Sprintf("%s (revoke: %t, %d users affected)", challenge.Name, revoke, len(userIDs))
+	if err := recordAudit(tx, actorID, AuditPurgeChallenge, challenge.ID, detail); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		fileStorage.Delete(attachment.ID)
+	}
+	invalidateScoreCaches()
+	return nil
+}
@@ -25,9 +25,12 @@ type User struct {
 	TwitterScreenName     string
 	IsAuthor              bool
 	IsOnsite              bool
+	TeamID                string
+	Team                  *Team `gorm:"foreignkey:TeamID"`
 	Score                 int
 	SolvedChallenges      []*Challenge `gorm:"many2many:user_solved_challenges;"`
 	OpenedHints           []*Hint      `gorm:"many2many:user_opened_hints;"`
+	OpenedChallenges      []*Challenge `gorm:"many2many:user_opened_challenges;"`
 	FoundFlags            []*FoundFlag `gorm:"many2many:user_found_flags;"`
 	WebShellPass          string
 	LastSeenChallengeID   string
@@ -43,13 +46,38 @@ type User struct {
 
 //FoundFlag a FoundFlag Record
 type FoundFlag struct {
-	ID          int `gorm:"primary_key"`
-	FlagID      string
-	ChallengeID string
-	Score       int
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   *time.Time
+	ID             int `gorm:"primary_key"`
+	FlagID         string
+	ChallengeID    string
+	Score          int
+	PenaltyPercent int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      *time.Time
+}
+
+//HintOpen a Record of the User's Opening the Hint
+type HintOpen struct {
+	UserID    string `gorm:"primary_key"`
+	HintID    string `gorm:"primary_key"`
+	CreatedAt *time.Time
+}
+
+//TableName the Name of the Table of HintOpen Records
+func (HintOpen) TableName() string {
+	return "user_opened_hints"
+}
+
+//ChallengeOpen a Record of a User's Opening a Challenge
+type ChallengeOpen struct {
+	UserID      string `gorm:"primary_key"`
+	ChallengeID string `gorm:"primary_key"`
+	CreatedAt   *time.Time
+}
+
+//TableName the Name of the Table of ChallengeOpen Records
+func (ChallengeOpen) TableName() string {
+	return "user_opened_challenges"
 }
 
 //Nobody a User Record which does Not Exist Actually
@@ -79,7 +107,7 @@ func GetUsers() ([]*User, error) {
 //GetUserByID Get the User Record by their ID
 func GetUserByID(id string, force bool) (*User, error) {
 	user := &User{}
-	err := db.Where(&User{ID: id}).Preload("SolvedChallenges").Preload("SolvedChallenges.Author").Preload("OpenedHints").Preload("FoundFlags").Preload("LastSeenChallenge").Preload("LastSolvedChallenge").Preload("Votes").First(user).Error
+	err := db.Where(&User{ID: id}).Preload("SolvedChallenges").Preload("SolvedChallenges.Author").Preload("SolvedChallenges.Prerequisites").Preload("SolvedChallenges.WhoSolved").Preload("SolvedChallenges.Solves", orderSolves).Preload("OpenedHints").Preload("FoundFlags").Preload("LastSeenChallenge").Preload("LastSeenChallenge.Prerequisites").Preload("LastSeenChallenge.WhoSolved").Preload("LastSeenChallenge.Solves", orderSolves).Preload("LastSolvedChallenge").Preload("LastSolvedChallenge.Prerequisites").Preload("LastSolvedChallenge.WhoSolved").Preload("LastSolvedChallenge.Solves", orderSolves).Preload("Votes").Preload("Team").First(user).Error
 	if err == gorm.ErrRecordNotFound && force {
 		name, iconURL, twitterScreenName, err := getUserInfo(id)
 		if err != nil {
@@ -103,7 +131,7 @@ func GetUserByID(id string, force bool) (*User, error) {
 //GetUserByToken Get the User Record by their Token
 func GetUserByToken(token string) (*User, error) {
 	user := new(User)
-	if err := db.Where(&User{Token: token}).Preload("SolvedChallenges").Preload("SolvedChallenges.Author").Preload("OpenedHints").Preload("FoundFlags").Preload("LastSeenChallenge").Preload("LastSolvedChallenge").Preload("Votes").First(user).Error; err != nil {
+	if err := db.Where(&User{Token: token}).Preload("SolvedChallenges").Preload("SolvedChallenges.Author").Preload("OpenedHints").Preload("FoundFlags").Preload("LastSeenChallenge").Preload("LastSolvedChallenge").Preload("Votes").Preload("Team").First(user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -142,40 +170,112 @@ func (user *User) MakeMeOnsite() error {
 	return db.Save(user).Error
 }
 
-//OpenHint Open the Hint
-func (user *User) OpenHint(id string) error {
-	idSplit := strings.Split(id, ":")
+//HintUnlock a Result of Opening a Hint
+type HintUnlock struct {
+	Hint                *Hint
+	AlreadyOpened       bool
+	TotalPenaltyPercent int
+}
+
+//ErrHintNotFound an Error due to the Hint Not Found
+var ErrHintNotFound = gorm.ErrRecordNotFound
+
+//ErrHintOutOfOrder an Error due to Opening a Hint before the Previous Ones
+var ErrHintOutOfOrder = fmt.Errorf("the previous hints have not been opened yet")
+
+//ErrHintNotReleased an Error due to Opening a Free Hint before its Release Time
+var ErrHintNotReleased = fmt.Errorf("the hint has not been released yet")
+
+//OpenHint Open the Hint unless the User or their Teammates have Already Opened it, Enforcing that the Hints of a Challenge except the Free Ones are Opened in Order and Deducting its Cost from the User's Score, or Only Preview the Result if dryRun is true
+func (user *User) OpenHint(id string, dryRun bool) (*HintUnlock, error) {
+	now := time.Now()
 
 	tx := db.Begin()
 	hint := &Hint{}
 	if err := tx.Where(&Hint{ID: id}).First(hint).Error; err != nil {
 		tx.Rollback()
-		return err
+		return nil, err
+	}
+	if hint.IsFree() {
+		tx.Rollback()
+		if !hint.IsReleased(now) {
+			return nil, ErrHintNotReleased
+		}
+		return &HintUnlock{Hint: hint, AlreadyOpened: true}, nil
 	}
 
-	challenge := &Challenge{}
-	if err := tx.Where(&Challenge{ID: idSplit[0]}).Preload("Flags").Find(&challenge).Error; err != nil {
+	hints := make([]*Hint, 0)
+	if err := tx.Where(&Hint{ChallengeID: hint.ChallengeID}).Order("position").Find(&hints).Error; err != nil {
 		tx.Rollback()
-		return err
+		return nil, err
+	}
+	hintIDs := make([]string, len(hints))
+	for i, h := range hints {
+		hintIDs[i] = h.ID
 	}
 
-	if err := tx.Model(user).Association("OpenedHints").Append(hint).Error; err != nil {
+	userIDs, err := teammateIDs(tx, user)
+	if err != nil {
 		tx.Rollback()
-		return err
+		return nil, err
+	}
+	hintOpens := make([]*HintOpen, 0)
+	if err := tx.Where("user_id IN (?) AND hint_id IN (?)", userIDs, hintIDs).Find(&hintOpens).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	opened := make(map[string]struct{}, len(hintOpens))
+	for _, hintOpen := range hintOpens {
+		opened[hintOpen.HintID] = struct{}{}
 	}
 
-	user.OpenedHints = append(user.OpenedHints, hint)
+	unlock := &HintUnlock{Hint: hint}
+	_, unlock.AlreadyOpened = opened[hint.ID]
+	for _, h := range hints {
+		if h.IsFree() {
+			continue
+		}
+		if _, ok := opened[h.ID]; ok || h.ID == hint.ID {
+			unlock.TotalPenaltyPercent += h.PenaltyPercent
+		} else if h.Position < hint.Position && !unlock.AlreadyOpened {
+			tx.Rollback()
+			return nil, ErrHintOutOfOrder
+		}
+	}
+	if unlock.AlreadyOpened || dryRun {
+		tx.Rollback()
+		return unlock, nil
+	}
 
-	if err := tx.Save(user).Error; err != nil {
+	//A Concurrent Request of the Same User may have Opened it, in which case this One Costs Nothing
+	result := tx.Exec("INSERT IGNORE INTO user_opened_hints (user_id, hint_id, created_at) VALUES (?, ?, ?)", user.ID, hint.ID, now)
+	if result.Error != nil {
 		tx.Rollback()
-		return err
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		unlock.AlreadyOpened = true
+		return unlock, nil
+	}
+
+	if hint.Cost > 0 {
+		if err := recalcScoresOfUsers(tx, []string{user.ID}); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		return err
+		return nil, err
+	}
+	user.OpenedHints = append(user.OpenedHints, hint)
+	if hint.Cost > 0 {
+		user.Score -= hint.Cost
+		invalidateScoreCaches()
 	}
 
-	return nil
+	return unlock, nil
 }
 
 //RecreateWebShellContainer (Re)create the User's Web Shell Container
@@ -194,9 +294,12 @@ func (user *User) RecreateWebShellContainer() error {
 	return db.Save(user).Error
 }
 
-//SetLastSeenChallengeID Set the Challenge's ID which the User Saw Last
+//SetLastSeenChallengeID Set the Challenge's ID which the User Saw Last, and Record that they have Opened the Challenge
 func (user *User) SetLastSeenChallengeID(challengeID string) error {
 	user.LastSeenChallengeID = challengeID
+	if err := db.Exec("INSERT IGNORE INTO user_opened_challenges (user_id, challenge_id, created_at) VALUES (?, ?, ?)", user.ID, challengeID, time.Now()).Error; err != nil {
+		return err
+	}
 	return db.Save(user).Error
 }
 
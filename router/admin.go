@@ -0,0 +1,106 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type recomputeReportJSON struct {
+	DryRun         bool             `json:"dry_run"`
+	FixedPenalties int              `json:"fixed_penalties"`
+	Diffs          []*scoreDiffJSON `json:"diffs"`
+}
+
+type scoreDiffJSON struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	IsTeam     bool   `json:"is_team"`
+	Stored     int    `json:"stored"`
+	Recomputed int    `json:"recomputed"`
+}
+
+func newRecomputeReportJSON(report *model.RecomputeReport) *recomputeReportJSON {
+	diffJSONs := make([]*scoreDiffJSON, len(report.Diffs))
+	for i, diff := range report.Diffs {
+		diffJSONs[i] = &scoreDiffJSON{
+			ID:         diff.ID,
+			Name:       diff.Name,
+			IsTeam:     diff.IsTeam,
+			Stored:     diff.Stored,
+			Recomputed: diff.Recomputed,
+		}
+	}
+	json := &recomputeReportJSON{
+		DryRun:         report.DryRun,
+		FixedPenalties: report.FixedPenalties,
+		Diffs:          diffJSONs,
+	}
+	return json
+}
+
+func parseDryRun(c echo.Context) (bool, error) {
+	str := c.QueryParam("dry_run")
+	if str == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(str)
+	if err != nil {
+		return false, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid dry_run: %v", err))
+	}
+	return dryRun, nil
+}
+
+//RecomputeScores the Method Handler of "POST /admin/recompute-scores"
+func RecomputeScores(c echo.Context) error {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := model.RecomputeScores(dryRun)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to recompute the scores: %v", err))
+	}
+
+	return c.JSON(http.StatusOK, newRecomputeReportJSON(report))
+}
+
+type auditLogJSON struct {
+	ID        int       `json:"id"`
+	Actor     *userJSON `json:"actor"`
+	Action    string    `json:"action"`
+	TargetID  string    `json:"target_id"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+//GetAuditLogs the Method Handler of "GET /admin/audit-logs"
+func GetAuditLogs(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	logs, err := model.GetAuditLogs()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	jsons := make([]*auditLogJSON, len(logs))
+	for i, log := range logs {
+		jsons[i] = &auditLogJSON{
+			ID:        log.ID,
+			Action:    log.Action,
+			TargetID:  log.TargetID,
+			Detail:    log.Detail,
+			CreatedAt: log.CreatedAt,
+		}
+		if log.Actor != nil {
+			jsons[i].Actor = newUserJSON(me, log.Actor)
+		}
+	}
+
+	return c.JSON(http.StatusOK, jsons)
+}
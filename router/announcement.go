@@ -0,0 +1,166 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type announcementJSON struct {
+	ID          string    `json:"id"`
+	Author      *userJSON `json:"author"`
+	ChallengeID string    `json:"challenge_id"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newAnnouncementJSON(me *model.User, announcement *model.Announcement) *announcementJSON {
+	var authorJSON *userJSON
+	if announcement.Author != nil {
+		authorJSON = newUserJSON(me, announcement.Author)
+	}
+	return &announcementJSON{
+		ID:          announcement.ID,
+		Author:      authorJSON,
+		ChallengeID: announcement.ChallengeID,
+		Title:       announcement.Title,
+		Body:        announcement.Body,
+		CreatedAt:   announcement.CreatedAt,
+		UpdatedAt:   announcement.UpdatedAt,
+	}
+}
+
+//notifyAnnouncement Push the Announcement to Everyone, or Only to the Authors while the Challenge it is about is Not Released
+func notifyAnnouncement(eventName string, announcement *model.Announcement) {
+	rooms := []string{"event"}
+	if !announcement.IsVisibleTo(model.Nobody, time.Now()) {
+		rooms = []string{authorsRoom}
+	}
+	announcementEventChan <- announcementEvent{
+		EventName:      eventName,
+		AnnouncementID: announcement.ID,
+		ChallengeID:    announcement.ChallengeID,
+		Title:          announcement.Title,
+		Body:           announcement.Body,
+		rooms:          rooms,
+	}
+}
+
+//GetAnnouncements the Method Handler of "GET /announcements"
+func GetAnnouncements(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	announcements, err := model.GetAnnouncements()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	now := time.Now()
+	jsons := make([]*announcementJSON, 0, len(announcements))
+	for _, announcement := range announcements {
+		if announcement.IsVisibleTo(me, now) {
+			jsons = append(jsons, newAnnouncementJSON(me, announcement))
+		}
+	}
+
+	return c.JSON(http.StatusOK, jsons)
+}
+
+//GetAnnouncement the Method Handler of "GET /announcements/:announcementID"
+func GetAnnouncement(c echo.Context) error {
+	announcementID := c.Param("announcementID")
+	me := c.Get("me").(*model.User)
+
+	announcement, err := model.GetAnnouncementByID(announcementID)
+	if err != nil {
+		if err == model.ErrAnnouncementNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if !announcement.IsVisibleTo(me, time.Now()) {
+		return echo.NewHTTPError(http.StatusNotFound)
+	}
+
+	json := newAnnouncementJSON(me, announcement)
+
+	return c.JSON(http.StatusOK, json)
+}
+
+//PostAnnouncement the Method Handler of "POST /announcements"
+func PostAnnouncement(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	req := &announcementJSON{}
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
+	}
+
+	announcement, err := model.NewAnnouncement(me.ID, req.ChallengeID, req.Title, req.Body)
+	if err != nil {
+		if err == model.ErrInvalidAnnouncement {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	notifyAnnouncement("announcement", announcement)
+
+	json := newAnnouncementJSON(me, announcement)
+
+	c.Response().Header().Set(echo.HeaderLocation, os.Getenv("API_URL_PREFIX")+"/announcements/"+announcement.ID)
+	return c.JSON(http.StatusCreated, json)
+}
+
+//PutAnnouncement the Method Handler of "PUT /announcements/:announcementID"
+func PutAnnouncement(c echo.Context) error {
+	announcementID := c.Param("announcementID")
+
+	announcement, err := model.GetAnnouncementByID(announcementID)
+	if err != nil {
+		if err == model.ErrAnnouncementNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	req := &announcementJSON{}
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
+	}
+
+	if err := announcement.Update(req.ChallengeID, req.Title, req.Body); err != nil {
+		if err == model.ErrInvalidAnnouncement {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	notifyAnnouncement("updateAnnouncement", announcement)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+//DeleteAnnouncement the Method Handler of "DELETE /announcements/:announcementID"
+func DeleteAnnouncement(c echo.Context) error {
+	announcementID := c.Param("announcementID")
+
+	announcement, err := model.GetAnnouncementByID(announcementID)
+	if err != nil {
+		if err == model.ErrAnnouncementNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := announcement.Delete(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	notifyAnnouncement("deleteAnnouncement", announcement)
+
+	return c.NoContent(http.StatusNoContent)
+}
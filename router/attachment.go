@@ -0,0 +1,114 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type attachmentJSON struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newAttachmentJSON(attachment *model.Attachment) *attachmentJSON {
+	return &attachmentJSON{
+		ID:          attachment.ID,
+		Name:        attachment.Name,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		SHA256:      attachment.SHA256,
+		URL:         os.Getenv("API_URL_PREFIX") + "/challenges/" + attachment.ChallengeID + "/files/" + attachment.ID,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
+
+//PostAttachment the Method Handler of "POST /challenges/:challengeID/files"
+func PostAttachment(c echo.Context) error {
+	challengeID := c.Param("challengeID")
+
+	challenge, err := model.GetChallengeByID(challengeID)
+	if err != nil {
+		if err == model.ErrChallengeNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the challenge record: %v", err))
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to get the file: %v", err))
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to open the file: %v", err))
+	}
+	defer file.Close()
+
+	contentType := fileHeader.Header.Get(echo.HeaderContentType)
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+	attachment, err := challenge.AddAttachment(fileHeader.Filename, contentType, file)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to store the file: %v", err))
+	}
+
+	json := newAttachmentJSON(attachment)
+	c.Response().Header().Set(echo.HeaderLocation, json.URL)
+	return c.JSON(http.StatusCreated, json)
+}
+
+//GetAttachment the Method Handler of "GET /challenges/:challengeID/files/:fileID"
+func GetAttachment(c echo.Context) error {
+	challengeID := c.Param("challengeID")
+	fileID := c.Param("fileID")
+	me := c.Get("me").(*model.User)
+
+	challenge, err := model.GetChallengeByID(challengeID)
+	if err != nil {
+		if err == model.ErrChallengeNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the challenge record: %v", err))
+	}
+	if !me.IsAuthor && !challenge.IsReleased(time.Now()) {
+		return echo.NewHTTPError(http.StatusNotFound)
+	}
+	if !me.IsAuthor && model.FinishTime().After(time.Now()) {
+		progress, err := model.GetProgress(me)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if !challenge.IsUnlocked(progress) {
+			return echo.NewHTTPError(http.StatusForbidden, model.ErrChallengeLocked.Error())
+		}
+	}
+
+	attachment, err := challenge.GetAttachment(fileID)
+	if err != nil {
+		if err == model.ErrAttachmentNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the attachment record: %v", err))
+	}
+	file, err := attachment.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to open the file: %v", err))
+	}
+	defer file.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", attachment.Name))
+	c.Response().Header().Set(echo.HeaderContentLength, fmt.Sprint(attachment.Size))
+	c.Response().Header().Set("ETag", fmt.Sprintf("%q", attachment.SHA256))
+	return c.Stream(http.StatusOK, attachment.ContentType, file)
+}
@@ -0,0 +1,180 @@
+package router
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type importReportJSON struct {
+	DryRun  bool                `json:"dry_run"`
+	Results []*bundleResultJSON `json:"results"`
+}
+
+type bundleResultJSON struct {
+	Path    string            `json:"path"`
+	ID      string            `json:"id"`
+	Genre   string            `json:"genre"`
+	Name    string            `json:"name"`
+	Action  string            `json:"action"`
+	Drift   []string          `json:"drift"`
+	Impacts []*editImpactJSON `json:"impacts"`
+}
+
+func newImportReportJSON(report *model.ImportReport) *importReportJSON {
+	resultJSONs := make([]*bundleResultJSON, len(report.Results))
+	for i, result := range report.Results {
+		resultJSONs[i] = &bundleResultJSON{
+			Path:    result.Path,
+			ID:      result.ID,
+			Genre:   result.Genre,
+			Name:    result.Name,
+			Action:  result.Action,
+			Drift:   result.Drift,
+			Impacts: newEditImpactJSONs(result.Impacts),
+		}
+	}
+	json := &importReportJSON{
+		DryRun:  report.DryRun,
+		Results: resultJSONs,
+	}
+	return json
+}
+
+//extractZip Extract the Zip Archive into the Directory, Rejecting the Entries Outside of it
+func extractZip(file io.ReaderAt, size int64, dir string) error {
+	reader, err := zip.NewReader(file, size)
+	if err != nil {
+		return err
+	}
+	for _, entry := range reader.File {
+		path := filepath.Join(dir, entry.Name)
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid entry: %s", entry.Name)
+		}
+		if entry.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		content, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		out, err := os.Create(path)
+		if err != nil {
+			content.Close()
+			return err
+		}
+		_, err = io.Copy(out, content)
+		content.Close()
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//writeZip Write the Files in the Directory as a Zip Archive
+func writeZip(w io.Writer, dir string) error {
+	writer := zip.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		entry, err := writer.Create(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(entry, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+//ImportBundles the Method Handler of "POST /admin/bundles"
+func ImportBundles(c echo.Context) error {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return err
+	}
+	confirm, err := parseConfirm(c)
+	if err != nil {
+		return err
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to get the file: %v", err))
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to open the file: %v", err))
+	}
+	defer file.Close()
+
+	dir, err := ioutil.TempDir("", "bundles")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer os.RemoveAll(dir)
+	if err := extractZip(file, fileHeader.Size, dir); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to extract the archive: %v", err))
+	}
+
+	report, err := model.ImportBundles(dir, dryRun, confirm, c.Get("me").(*model.User).ID)
+	if err == model.ErrUnconfirmedEdit {
+		return c.JSON(http.StatusConflict, newImportReportJSON(report))
+	}
+	if _, ok := err.(*model.InvalidBundleError); ok {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid bundle: %v", err))
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to import the bundles: %v", err))
+	}
+
+	return c.JSON(http.StatusOK, newImportReportJSON(report))
+}
+
+//ExportBundles the Method Handler of "GET /admin/bundles"
+func ExportBundles(c echo.Context) error {
+	dir, err := ioutil.TempDir("", "bundles")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := model.ExportBundles(dir); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to export the bundles: %v", err))
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="challenges.zip"`)
+	c.Response().WriteHeader(http.StatusOK)
+	return writeZip(c.Response(), dir)
+}
@@ -7,7 +7,6 @@ import (
 	"os"
 	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"git.trapti.tech/CPCTF2019/scoreserver/model"
@@ -15,32 +14,138 @@ import (
 )
 
 type challengeJSON struct {
-	ID        string      `json:"id"`
-	Genre     string      `json:"genre"`
-	Name      string      `json:"name"`
-	Author    *userJSON   `json:"author"`
-	Score     int         `json:"score"`
-	RealScore int         `json:"real_score"`
-	Caption   string      `json:"caption"`
-	Hints     []*hintJSON `json:"hints"`
-	Flags     []*flagJSON `json:"flags"`
-	Answer    string      `json:"answer"`
-	WhoSolved []*userJSON `json:"who_solved"`
-	Solved    bool        `json:"solved"`
+	ID            string              `json:"id"`
+	Genre         string              `json:"genre"`
+	Name          string              `json:"name"`
+	Author        *userJSON           `json:"author"`
+	Score         int                 `json:"score"`
+	RealScore     int                 `json:"real_score"`
+	InitialScore  int                 `json:"initial_score"`
+	CurrentScore  int                 `json:"current_score"`
+	ScoringMode   string              `json:"scoring_mode"`
+	MinimumScore  int                 `json:"minimum_score"`
+	Decay         int                 `json:"decay"`
+	Bonuses       []int               `json:"bonuses"`
+	Caption       string              `json:"caption"`
+	Hints         []*hintJSON         `json:"hints"`
+	Flags         []*flagJSON         `json:"flags"`
+	Files         []*attachmentJSON   `json:"files"`
+	FlagFormat    string              `json:"flag_format"`
+	Answer        string              `json:"answer"`
+	WhoSolved     []*userJSON         `json:"who_solved"`
+	FirstBloods   []*solveJSON        `json:"first_bloods"`
+	Solved        bool                `json:"solved"`
+	Locked        bool                `json:"locked"`
+	ReleaseAt     *time.Time          `json:"release_at"`
+	HideAt        *time.Time          `json:"hide_at"`
+	Prerequisites []*prerequisiteJSON `json:"prerequisites"`
+}
+
+type prerequisiteJSON struct {
+	ChallengeID string `json:"challenge_id"`
+	Genre       string `json:"genre"`
+	Score       int    `json:"score"`
+}
+
+type solveJSON struct {
+	User     *userJSON `json:"user"`
+	SolvedAt time.Time `json:"solved_at"`
+	Bonus    int       `json:"bonus"`
 }
 
 type hintJSON struct {
-	ID             string `json:"id"`
-	Caption        string `json:"caption"`
-	PenaltyPercent int    `json:"penalty"`
+	ID             string     `json:"id"`
+	Caption        string     `json:"caption"`
+	PenaltyPercent int        `json:"penalty"`
+	Cost           int        `json:"cost"`
+	ReleaseAt      *time.Time `json:"release_at"`
+	Remove         bool       `json:"remove,omitempty"`
 }
 
 type flagJSON struct {
-	ID        string `json:"id"`
-	Flag      string `json:"flag"`
-	Score     int    `json:"score"`
-	RealScore int    `json:"real_score"`
-	Found     bool   `json:"found"`
+	ID           string `json:"id"`
+	Flag         string `json:"flag"`
+	MatchMode    string `json:"match_mode"`
+	Secret       string `json:"secret"`
+	Score        int    `json:"score"`
+	RealScore    int    `json:"real_score"`
+	CurrentScore int    `json:"current_score"`
+	Found        bool   `json:"found"`
+	Remove       bool   `json:"remove,omitempty"`
+}
+
+type editImpactJSON struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	Users  int    `json:"users"`
+}
+
+func newEditImpactJSONs(impacts []*model.EditImpact) []*editImpactJSON {
+	jsons := make([]*editImpactJSON, len(impacts))
+	for i, impact := range impacts {
+		jsons[i] = &editImpactJSON{
+			Kind:   impact.Kind,
+			ID:     impact.ID,
+			Action: impact.Action,
+			Users:  impact.Users,
+		}
+	}
+	return jsons
+}
+
+//parseConfirm Parse the "confirm" Query Parameter, which Defaults to false
+func parseConfirm(c echo.Context) (bool, error) {
+	str := c.QueryParam("confirm")
+	if str == "" {
+		return false, nil
+	}
+	confirm, err := strconv.ParseBool(str)
+	if err != nil {
+		return false, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid confirm: %v", err))
+	}
+	return confirm, nil
+}
+
+//unconfirmedEdit Respond the Effects of the Edit which Needs to be Confirmed
+func unconfirmedEdit(c echo.Context, impacts []*model.EditImpact) error {
+	return c.JSON(http.StatusConflict, &struct {
+		Message string            `json:"message"`
+		Impacts []*editImpactJSON `json:"impacts"`
+	}{
+		Message: model.ErrUnconfirmedEdit.Error() + "; retry with confirm=true to apply it",
+		Impacts: newEditImpactJSONs(impacts),
+	})
+}
+
+func newHintEdits(jsons []*hintJSON) []*model.HintEdit {
+	edits := make([]*model.HintEdit, len(jsons))
+	for i, json := range jsons {
+		edits[i] = &model.HintEdit{
+			ID:             json.ID,
+			Caption:        json.Caption,
+			PenaltyPercent: json.PenaltyPercent,
+			Cost:           json.Cost,
+			ReleaseAt:      json.ReleaseAt,
+			Remove:         json.Remove,
+		}
+	}
+	return edits
+}
+
+func newFlagEdits(jsons []*flagJSON) []*model.FlagEdit {
+	edits := make([]*model.FlagEdit, len(jsons))
+	for i, json := range jsons {
+		edits[i] = &model.FlagEdit{
+			ID:        json.ID,
+			Flag:      json.Flag,
+			MatchMode: json.MatchMode,
+			Secret:    json.Secret,
+			Score:     json.Score,
+			Remove:    json.Remove,
+		}
+	}
+	return edits
 }
 
 func containsUser(slice []*model.User, x *model.User) bool {
@@ -52,47 +157,84 @@ func containsUser(slice []*model.User, x *model.User) bool {
 	return false
 }
 
-func makeSolvedOpenedFoundMaps(me *model.User) (map[string]struct{}, map[string]struct{}, map[string]struct{}) {
-	solvedMap := make(map[string]struct{}, 0)
-	for _, challenge := range me.SolvedChallenges {
-		solvedMap[challenge.ID] = struct{}{}
+func makeSolvedOpenedFoundMaps(me *model.User) (map[string]struct{}, map[string]struct{}, map[string]struct{}, error) {
+	teammates, err := me.Teammates()
+	if err != nil {
+		return nil, nil, nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the teammates: %v", err))
 	}
+	solvedMap := make(map[string]struct{}, 0)
 	openedMap := make(map[string]struct{}, 0)
-	for _, hint := range me.OpenedHints {
-		openedMap[hint.ID] = struct{}{}
-	}
 	foundMap := make(map[string]struct{}, 0)
-	for _, _flag := range me.FoundFlags {
-		foundMap[_flag.FlagID] = struct{}{}
+	for _, user := range teammates {
+		for _, challenge := range user.SolvedChallenges {
+			solvedMap[challenge.ID] = struct{}{}
+		}
+		for _, hint := range user.OpenedHints {
+			openedMap[hint.ID] = struct{}{}
+		}
+		for _, _flag := range user.FoundFlags {
+			foundMap[_flag.FlagID] = struct{}{}
+		}
+	}
+	return solvedMap, openedMap, foundMap, nil
+}
+
+func newPrerequisiteJSONs(challenge *model.Challenge) []*prerequisiteJSON {
+	jsons := make([]*prerequisiteJSON, len(challenge.Prerequisites))
+	for i, prerequisite := range challenge.Prerequisites {
+		jsons[i] = &prerequisiteJSON{
+			ChallengeID: prerequisite.RequiredChallengeID,
+			Genre:       prerequisite.Genre,
+			Score:       prerequisite.GenreScore,
+		}
 	}
-	return solvedMap, openedMap, foundMap
+	return jsons
 }
 
-func newChallengeJSON(me *model.User, challenge *model.Challenge, solvedMap, openedMap, foundMap map[string]struct{}) *challengeJSON {
+func newPrerequisites(jsons []*prerequisiteJSON) []*model.Prerequisite {
+	prerequisites := make([]*model.Prerequisite, len(jsons))
+	for i, json := range jsons {
+		prerequisites[i] = &model.Prerequisite{
+			RequiredChallengeID: json.ChallengeID,
+			Genre:               json.Genre,
+			GenreScore:          json.Score,
+		}
+	}
+	return prerequisites
+}
+
+func newChallengeJSON(me *model.User, challenge *model.Challenge, solvedMap, openedMap, foundMap map[string]struct{}, progress *model.Progress) *challengeJSON {
 	authorJSON := newUserJSON(me, challenge.Author)
 
-	score := challenge.Score
-	penaltySum := 0
-	for _, hint := range challenge.Hints {
-		_, opened := openedMap[hint.ID]
-		if opened {
-			penaltySum += hint.PenaltyPercent
+	frozen := isFrozenFor(me)
+	solves := len(challenge.WhoSolved)
+	if frozen {
+		solves = 0
+		for _, solve := range challenge.Solves {
+			if model.IsVisibleWhileFrozen(solve.CreatedAt) {
+				solves++
+			}
 		}
 	}
-	score = score * (100 - penaltySum) / 100
+	currentScore := challenge.ScoreAt(solves)
+	penaltySum := challenge.OpenedPenaltyPercent(openedMap)
+	score := model.ApplyPenalty(currentScore, penaltySum)
 
 	now, finish := time.Now(), model.FinishTime()
 	_, solved := solvedMap[challenge.ID]
+	locked := !me.IsAuthor && finish.After(now) && !challenge.IsUnlocked(progress)
 
 	hintJSONs := make([]*hintJSON, len(challenge.Hints))
 	for i, hint := range challenge.Hints {
 		_, opened := openedMap[hint.ID]
 
-		canISeeHint := !finish.After(now) || opened || solved || me.IsAuthor
+		canISeeHint := (!finish.After(now) || opened || hint.IsReleased(now) || solved || me.IsAuthor) && !locked
 		hintJSONs[i] = &hintJSON{
 			ID:             hint.ID,
 			Caption:        map[bool]string{true: hint.Caption}[canISeeHint],
 			PenaltyPercent: hint.PenaltyPercent,
+			Cost:           hint.Cost,
+			ReleaseAt:      hint.ReleaseAt,
 		}
 	}
 
@@ -100,36 +242,90 @@ func newChallengeJSON(me *model.User, challenge *model.Challenge, solvedMap, ope
 	for i, _flag := range challenge.Flags {
 		_, found := foundMap[_flag.ID]
 
-		canISeeFlag := !finish.After(now) || found || solved || me.IsAuthor
+		canISeeFlag := (!finish.After(now) || found || solved || me.IsAuthor) && !locked
+		flagScore := challenge.FlagScoreAt(_flag, solves)
+		myFlag := _flag.Flag
+		if !me.IsAuthor {
+			myFlag = _flag.FlagFor(me.ID)
+		}
 		flagJSONs[i] = &flagJSON{
-			ID:        _flag.ID,
-			Flag:      map[bool]string{true: _flag.Flag}[canISeeFlag],
-			Score:     _flag.Score * (100 - penaltySum) / 100,
-			RealScore: _flag.Score,
-			Found:     found,
+			ID:           _flag.ID,
+			Flag:         map[bool]string{true: myFlag}[canISeeFlag],
+			MatchMode:    _flag.MatchMode,
+			Secret:       map[bool]string{true: _flag.Secret}[me.IsAuthor],
+			Score:        model.ApplyPenalty(flagScore, penaltySum),
+			RealScore:    _flag.Score,
+			CurrentScore: flagScore,
+			Found:        found,
 		}
 	}
 	sort.SliceStable(flagJSONs, func(i, j int) bool { return flagJSONs[i].RealScore < flagJSONs[j].RealScore })
 
-	whoSolvedJSONs := make([]*userJSON, len(challenge.WhoSolved))
-	for i := 0; i < len(challenge.WhoSolved); i++ {
-		whoSolvedJSONs[i] = newUserJSON(me, challenge.WhoSolved[i])
+	whoSolvedJSONs := make([]*userJSON, 0, len(challenge.WhoSolved))
+	for _, user := range challenge.WhoSolved {
+		if rank := challenge.SolveRank(user.ID); frozen && user.ID != me.ID && (rank < 0 || !model.IsVisibleWhileFrozen(challenge.Solves[rank].CreatedAt)) {
+			continue
+		}
+		whoSolvedJSONs = append(whoSolvedJSONs, newUserJSON(me, user))
+	}
+
+	bonuses := challenge.BonusPercents()
+	firstBloodJSONs := make([]*solveJSON, 0, len(bonuses))
+	for i, solve := range challenge.Solves {
+		if i >= len(bonuses) {
+			break
+		}
+		if frozen && solve.UserID != me.ID && !model.IsVisibleWhileFrozen(solve.CreatedAt) {
+			continue
+		}
+		for _, user := range challenge.WhoSolved {
+			if user.ID == solve.UserID {
+				firstBloodJSONs = append(firstBloodJSONs, &solveJSON{
+					User:     newUserJSON(me, user),
+					SolvedAt: solve.CreatedAt,
+					Bonus:    challenge.BonusAt(i),
+				})
+				break
+			}
+		}
+	}
+
+	attachmentJSONs := make([]*attachmentJSON, 0, len(challenge.Attachments))
+	if !locked {
+		for _, attachment := range challenge.Attachments {
+			attachmentJSONs = append(attachmentJSONs, newAttachmentJSON(attachment))
+		}
 	}
 
 	canISeeAnswer := !finish.After(now) || solved || me.IsAuthor
 	json := &challengeJSON{
-		ID:        challenge.ID,
-		Genre:     challenge.Genre,
-		Name:      challenge.Name,
-		Author:    authorJSON,
-		Score:     score,
-		RealScore: challenge.Score,
-		Caption:   challenge.Caption,
-		Hints:     hintJSONs,
-		Flags:     flagJSONs,
-		Answer:    map[bool]string{true: challenge.Answer}[canISeeAnswer],
-		WhoSolved: whoSolvedJSONs,
-		Solved:    solved,
+		ID:           challenge.ID,
+		Genre:        challenge.Genre,
+		Name:         challenge.Name,
+		Author:       authorJSON,
+		Score:        score,
+		RealScore:    challenge.Score,
+		InitialScore: challenge.Score,
+		CurrentScore: currentScore,
+		ScoringMode:  challenge.ScoringMode,
+		MinimumScore: challenge.MinimumScore,
+		Decay:        challenge.Decay,
+		Bonuses:      bonuses,
+		Caption:      map[bool]string{true: challenge.Caption}[!locked],
+		Hints:        hintJSONs,
+		Flags:        flagJSONs,
+		Files:        attachmentJSONs,
+		FlagFormat:   challenge.FlagFormat,
+		Answer:       map[bool]string{true: challenge.Answer}[canISeeAnswer],
+		WhoSolved:    whoSolvedJSONs,
+		FirstBloods:  firstBloodJSONs,
+		Solved:       solved,
+		Locked:       locked,
+		ReleaseAt:    challenge.ReleaseAt,
+		HideAt:       challenge.HideAt,
+	}
+	if me.IsAuthor {
+		json.Prerequisites = newPrerequisiteJSONs(challenge)
 	}
 	return json
 }
@@ -143,10 +339,21 @@ func GetChallenges(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	solvedMap, openedMap, foundMap := makeSolvedOpenedFoundMaps(me)
-	jsons := make([]*challengeJSON, len(challenges))
-	for i := 0; i < len(challenges); i++ {
-		jsons[i] = newChallengeJSON(me, challenges[i], solvedMap, openedMap, foundMap)
+	progress, err := model.GetProgress(me)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	now := time.Now()
+	solvedMap, openedMap, foundMap, err := makeSolvedOpenedFoundMaps(me)
+	if err != nil {
+		return err
+	}
+	jsons := make([]*challengeJSON, 0, len(challenges))
+	for _, challenge := range challenges {
+		if !me.IsAuthor && !challenge.IsReleased(now) {
+			continue
+		}
+		jsons = append(jsons, newChallengeJSON(me, challenge, solvedMap, openedMap, foundMap, progress))
 	}
 
 	return c.JSON(http.StatusOK, jsons)
@@ -164,11 +371,21 @@ func GetChallenge(c echo.Context) error {
 		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	if !me.IsAuthor && !challenge.IsReleased(time.Now()) {
+		return echo.NewHTTPError(http.StatusNotFound)
+	}
 
-	solvedMap, openedMap, foundMap := makeSolvedOpenedFoundMaps(me)
-	json := newChallengeJSON(me, challenge, solvedMap, openedMap, foundMap)
+	progress, err := model.GetProgress(me)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	solvedMap, openedMap, foundMap, err := makeSolvedOpenedFoundMaps(me)
+	if err != nil {
+		return err
+	}
+	json := newChallengeJSON(me, challenge, solvedMap, openedMap, foundMap, progress)
 
-	if _, solved := solvedMap[challengeID]; me.ID != model.Nobody.ID && !solved {
+	if _, solved := solvedMap[challengeID]; me.ID != model.Nobody.ID && !solved && !json.Locked {
 		go func() {
 			if err := me.SetLastSeenChallengeID(challengeID); err != nil {
 				log.Println(err)
@@ -193,27 +410,38 @@ func PostChallenge(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
 	}
 
-	captions, penalties := make([]string, len(req.Hints)), make([]int, len(req.Hints))
-	for _, _hintJSON := range req.Hints {
-		idSplit := strings.Split(_hintJSON.ID, ":")
-		i, _ := strconv.Atoi(idSplit[1])
-		captions[i] = _hintJSON.Caption
-		penalties[i] = _hintJSON.PenaltyPercent
+	hintEdits, flagEdits := make([]*model.HintEdit, 0, len(req.Hints)), make([]*model.FlagEdit, 0, len(req.Flags))
+	for _, edit := range newHintEdits(req.Hints) {
+		if !edit.Remove {
+			edit.ID = ""
+			hintEdits = append(hintEdits, edit)
+		}
 	}
-	flags, scores := make([]string, len(req.Flags)), make([]int, len(req.Flags))
-	for _, _flagJSON := range req.Flags {
-		idSplit := strings.Split(_flagJSON.ID, ":")
-		i, _ := strconv.Atoi(idSplit[1])
-		flags[i] = _flagJSON.Flag
-		scores[i] = _flagJSON.Score
+	for _, edit := range newFlagEdits(req.Flags) {
+		if !edit.Remove {
+			edit.ID = ""
+			flagEdits = append(flagEdits, edit)
+		}
 	}
-	challenge, err := model.NewChallenge(req.Genre, req.Name, req.Author.ID, req.Score, req.Caption, captions, penalties, flags, scores, req.Answer)
+	challenge, err := model.NewChallenge(req.Genre, req.Name, req.Author.ID, req.Score, req.ScoringMode, req.MinimumScore, req.Decay, req.Bonuses, req.Caption, hintEdits, flagEdits, req.FlagFormat, req.Answer, newPrerequisites(req.Prerequisites), req.ReleaseAt, req.HideAt)
 	if err != nil {
+		if err == model.ErrInvalidScoring || err == model.ErrInvalidFlag || err == model.ErrInvalidPrerequisite || err == model.ErrInvalidSchedule || err == model.ErrInvalidEdit || err == model.ErrInvalidHint {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	solvedMap, openedMap, foundMap := makeSolvedOpenedFoundMaps(me)
-	json := newChallengeJSON(me, challenge, solvedMap, openedMap, foundMap)
+	progress, err := model.GetProgress(me)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	solvedMap, openedMap, foundMap, err := makeSolvedOpenedFoundMaps(me)
+	if err != nil {
+		return err
+	}
+	json := newChallengeJSON(me, challenge, solvedMap, openedMap, foundMap, progress)
+
+	rescheduleReleases()
 
 	c.Response().Header().Set(echo.HeaderLocation, os.Getenv("API_URL_PREFIX")+"/challenges/"+challenge.ID)
 	return c.JSON(http.StatusCreated, json)
@@ -222,6 +450,7 @@ func PostChallenge(c echo.Context) error {
 //PutChallenge the Method Handler of "PUT /challenges/:challengeID"
 func PutChallenge(c echo.Context) error {
 	challengeID := c.Param("challengeID")
+	me := c.Get("me").(*model.User)
 
 	challenge, err := model.GetChallengeByID(challengeID)
 	if err != nil {
@@ -236,23 +465,30 @@ func PutChallenge(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
 	}
 
-	captions, penalties := make([]string, len(req.Hints)), make([]int, len(req.Hints))
-	for _, _hintJSON := range req.Hints {
-		idSplit := strings.Split(_hintJSON.ID, ":")
-		i, _ := strconv.Atoi(idSplit[1])
-		captions[i] = _hintJSON.Caption
-		penalties[i] = _hintJSON.PenaltyPercent
+	confirm, err := parseConfirm(c)
+	if err != nil {
+		return err
+	}
+
+	hintEdits, flagEdits := newHintEdits(req.Hints), newFlagEdits(req.Flags)
+	impacts, err := challenge.EditImpacts(req.Score, hintEdits, flagEdits)
+	if err != nil {
+		if err == model.ErrInvalidEdit {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
-	flags, scores := make([]string, len(req.Flags)), make([]int, len(req.Flags))
-	for _, _flagJSON := range req.Flags {
-		idSplit := strings.Split(_flagJSON.ID, ":")
-		i, _ := strconv.Atoi(idSplit[1])
-		flags[i] = _flagJSON.Flag
-		scores[i] = _flagJSON.Score
+	if len(impacts) > 0 && !confirm {
+		return unconfirmedEdit(c, impacts)
 	}
-	if err := challenge.Update(req.Genre, req.Name, req.Author.ID, req.Score, req.Caption, captions, penalties, flags, scores, req.Answer); err != nil {
+
+	if err := challenge.Update(req.Genre, req.Name, req.Author.ID, req.Score, req.ScoringMode, req.MinimumScore, req.Decay, req.Bonuses, req.Caption, hintEdits, flagEdits, req.FlagFormat, req.Answer, newPrerequisites(req.Prerequisites), req.ReleaseAt, req.HideAt, me.ID); err != nil {
+		if err == model.ErrInvalidScoring || err == model.ErrInvalidFlag || err == model.ErrInvalidPrerequisite || err == model.ErrInvalidSchedule || err == model.ErrInvalidEdit || err == model.ErrInvalidHint {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	rescheduleReleases()
 
 	return c.NoContent(http.StatusNoContent)
 }
@@ -260,6 +496,7 @@ func PutChallenge(c echo.Context) error {
 //DeleteChallenge the Method Handler of "DELETE /challenges/:challengeID"
 func DeleteChallenge(c echo.Context) error {
 	challengeID := c.Param("challengeID")
+	me := c.Get("me").(*model.User)
 
 	challenge, err := model.GetChallengeByID(challengeID)
 	if err != nil {
@@ -269,7 +506,7 @@ func DeleteChallenge(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the challenge record: %v", err))
 	}
 
-	if err := challenge.Delete(); err != nil {
+	if err := challenge.Delete(me.ID); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -288,7 +525,14 @@ func CheckAnswer(c echo.Context) error {
 		}
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the challenge record: %v", err))
 	}
-	if containsUser(challenge.WhoSolved, me) {
+	if !me.IsAuthor && !challenge.IsReleased(time.Now()) {
+		return echo.NewHTTPError(http.StatusNotFound)
+	}
+	solvedMap, _, _, err := makeSolvedOpenedFoundMaps(me)
+	if err != nil {
+		return err
+	}
+	if _, solved := solvedMap[challengeID]; solved || containsUser(challenge.WhoSolved, me) {
 		return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("you already solved the challenge"))
 	}
 
@@ -298,24 +542,74 @@ func CheckAnswer(c echo.Context) error {
 	if err := c.Bind(req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
 	}
-	isCorrect, scoreDelta, err := challenge.CheckAnswer(me, req.Flag)
+	submission, err := challenge.CheckAnswer(me, req.Flag, c.RealIP(), c.Request().UserAgent())
 	if err != nil {
+		if err == model.ErrChallengeLocked {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to check the answer: %v", err))
 	}
-	sendFlagEventChan <- sendFlagEvent{
-		EventName: "sendFlag",
-		UserID:    me.ID,
-		Username:  me.Name,
-		ProblemID: challengeID,
-		Score:     scoreDelta,
-		IsSolved:  isCorrect,
+	if frozen, err := model.IsFrozen(); err != nil {
+		log.Println(err)
+	} else if !frozen {
+		sendFlagEventChan <- sendFlagEvent{
+			EventName: "sendFlag",
+			UserID:    me.ID,
+			Username:  me.Name,
+			ProblemID: challengeID,
+			Score:     submission.Score,
+			IsSolved:  submission.IsCorrect,
+		}
+		if len(challenge.WhoSolved) == 1 && challenge.WhoSolved[0].ID == me.ID {
+			firstBloodEventChan <- firstBloodEvent{
+				EventName: "firstBlood",
+				UserID:    me.ID,
+				Username:  me.Name,
+				ProblemID: challengeID,
+				Bonus:     challenge.BonusAt(0),
+			}
+		}
 	}
-	if !isCorrect {
+	if !submission.IsCorrect {
 		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("the flag is wrong"))
 	}
 	return c.Redirect(http.StatusSeeOther, os.Getenv("API_URL_PREFIX")+"/challenges/"+challengeID)
 }
 
+//GetUserFlags the Method Handler of "GET /challenges/:challengeID/flags/:userID"
+func GetUserFlags(c echo.Context) error {
+	challengeID := c.Param("challengeID")
+	userID := c.Param("userID")
+
+	challenge, err := model.GetChallengeByID(challengeID)
+	if err != nil {
+		if err == model.ErrChallengeNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the challenge record: %v", err))
+	}
+
+	if _, err := model.GetUserByID(userID, false); err != nil {
+		if err == model.ErrUserNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the user record: %v", err))
+	}
+
+	jsons := make([]*flagJSON, len(challenge.Flags))
+	for i, _flag := range challenge.Flags {
+		jsons[i] = &flagJSON{
+			ID:        _flag.ID,
+			Flag:      _flag.FlagFor(userID),
+			MatchMode: _flag.MatchMode,
+			Score:     _flag.Score,
+			RealScore: _flag.Score,
+		}
+	}
+
+	return c.JSON(http.StatusOK, jsons)
+}
+
 //GetVote the Method Handler of "GET /challenges/:challengeID/votes/:userID"
 func GetVote(c echo.Context) error {
 	challengeID := c.Param("challengeID")
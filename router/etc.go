@@ -2,7 +2,10 @@ package router
 
 import (
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"git.trapti.tech/CPCTF2019/scoreserver/model"
@@ -33,3 +36,35 @@ func EnsureContestNotFinished(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+//EnsureNotRateLimited Ensure I have not Submitted Flags Too Many Times
+func EnsureNotRateLimited(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		me := c.Get("me").(*model.User)
+		if me.IsAuthor {
+			return next(c)
+		}
+		wait, err := model.CheckSubmissionRate(me.ID, c.Param("challengeID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to check the submission rate: %v", err))
+		}
+		if wait > 0 {
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			return echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf("you have submitted too many flags"))
+		}
+		return next(c)
+	}
+}
+
+//isFrozenFor Whether the Scoreboard is Frozen for Me
+func isFrozenFor(me *model.User) bool {
+	if me.IsAuthor {
+		return false
+	}
+	frozen, err := model.IsFrozen()
+	if err != nil {
+		log.Println(err)
+		return true
+	}
+	return frozen
+}
@@ -2,16 +2,25 @@ package router
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
 	"github.com/trevex/golem"
 )
 
 var (
-	Ws                   *golem.Router
-	Room                 = golem.NewRoomManager()
-	openProblemEventChan chan openProblemEvent
-	sendFlagEventChan    chan sendFlagEvent
+	Ws                    *golem.Router
+	Room                  = golem.NewRoomManager()
+	openProblemEventChan  chan openProblemEvent
+	sendFlagEventChan     chan sendFlagEvent
+	firstBloodEventChan   chan firstBloodEvent
+	releaseEventChan      chan releaseEvent
+	hintOpenEventChan     chan hintOpenEvent
+	questionEventChan     chan questionEvent
+	announcementEventChan chan announcementEvent
+	rescheduleChan        = make(chan struct{}, 1)
 )
 
 type openProblemEvent struct {
@@ -29,12 +38,124 @@ type sendFlagEvent struct {
 	IsSolved  bool   `json:"isSolved"`
 }
 
+type firstBloodEvent struct {
+	EventName string `json:"eventName"`
+	UserID    string `json:"userID"`
+	Username  string `json:"username"`
+	ProblemID string `json:"problemID"`
+	Bonus     int    `json:"bonus"`
+}
+
+type hintOpenEvent struct {
+	EventName string `json:"eventName"`
+	UserID    string `json:"userID"`
+	ProblemID string `json:"problemID"`
+	HintID    string `json:"hintID"`
+}
+
+type questionEvent struct {
+	EventName   string `json:"eventName"`
+	QuestionID  string `json:"questionID"`
+	ChallengeID string `json:"problemID"`
+	Status      string `json:"status"`
+	MessageID   int    `json:"messageID"`
+	UserID      string `json:"userID"`
+	Body        string `json:"body"`
+	Broadcast   bool   `json:"broadcast"`
+	rooms       []string
+}
+
+type announcementEvent struct {
+	EventName      string `json:"eventName"`
+	AnnouncementID string `json:"announcementID"`
+	ChallengeID    string `json:"problemID"`
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	rooms          []string
+}
+
+type releaseEvent struct {
+	EventName string `json:"eventName"`
+	ProblemID string `json:"problemID"`
+	Genre     string `json:"genre"`
+	Name      string `json:"name"`
+}
+
+//rescheduleReleases Notify the Release Watcher that the Release Schedule may have Changed
+func rescheduleReleases() {
+	select {
+	case rescheduleChan <- struct{}{}:
+	default:
+	}
+}
+
+//watchReleases Send the Release Events at the Moment the Challenges are Released
+func watchReleases() {
+	last := time.Now()
+	for {
+		wait := time.Minute
+		next, err := model.GetNextReleaseTime(last)
+		if err != nil {
+			log.Println(err)
+		} else if next != nil && next.Sub(time.Now()) < wait {
+			wait = next.Sub(time.Now())
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-rescheduleChan:
+			timer.Stop()
+		}
+
+		now := time.Now()
+		challenges, err := model.GetReleasedChallenges(last, now)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		for _, challenge := range challenges {
+			releaseEventChan <- releaseEvent{
+				EventName: "release",
+				ProblemID: challenge.ID,
+				Genre:     challenge.Genre,
+				Name:      challenge.Name,
+			}
+		}
+		last = now
+	}
+}
+
 func connClose(conn *golem.Connection) {
 	Room.LeaveAll(conn)
 }
 
+//authorsRoom the Room which All Authors Join
+const authorsRoom = "authors"
+
+//userRoom the Room which Only the User Joins
+func userRoom(userID string) string {
+	return "user:" + userID
+}
+
 func connOpen(conn *golem.Connection, req *http.Request) {
 	Room.Join("event", conn)
+
+	cookie, err := req.Cookie("token")
+	if err != nil {
+		return
+	}
+	me, err := model.GetUserByToken(cookie.Value)
+	if err != nil {
+		if err != model.ErrUserNotFound {
+			log.Println(err)
+		}
+		return
+	}
+	Room.Join(userRoom(me.ID), conn)
+	if me.IsAuthor {
+		Room.Join(authorsRoom, conn)
+	}
 }
 
 func SetupWs() error {
@@ -51,6 +172,11 @@ func SetupWs() error {
 
 	openProblemEventChan = make(chan openProblemEvent)
 	sendFlagEventChan = make(chan sendFlagEvent)
+	firstBloodEventChan = make(chan firstBloodEvent)
+	releaseEventChan = make(chan releaseEvent)
+	hintOpenEventChan = make(chan hintOpenEvent)
+	questionEventChan = make(chan questionEvent)
+	announcementEventChan = make(chan announcementEvent)
 
 	go func() {
 		for {
@@ -60,9 +186,29 @@ func SetupWs() error {
 
 			case event := <-sendFlagEventChan:
 				Room.Emit("event", "", event)
+
+			case event := <-firstBloodEventChan:
+				Room.Emit("event", "", event)
+
+			case event := <-releaseEventChan:
+				Room.Emit("event", "", event)
+
+			case event := <-hintOpenEventChan:
+				Room.Emit("event", "", event)
+
+			case event := <-questionEventChan:
+				for _, room := range event.rooms {
+					Room.Emit(room, "", event)
+				}
+
+			case event := <-announcementEventChan:
+				for _, room := range event.rooms {
+					Room.Emit(room, "", event)
+				}
 			}
 		}
 	}()
+	go watchReleases()
 
 	return nil
 }
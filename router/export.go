@@ -0,0 +1,110 @@
+package router
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type ctftimeJSON struct {
+	Standings []*ctftimeStandingJSON `json:"standings"`
+}
+
+type ctftimeStandingJSON struct {
+	Pos   int    `json:"pos"`
+	Team  string `json:"team"`
+	Score int    `json:"score"`
+}
+
+func getRankingForExport(frozen bool) ([]*model.RankingEntry, error) {
+	if model.IsTeamMode() {
+		return model.GetTeamRanking(frozen)
+	}
+	return model.GetRanking(frozen, nil)
+}
+
+//ExportCTFtime the Method Handler of "GET /export/ctftime"
+func ExportCTFtime(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	entries, err := getRankingForExport(isFrozenFor(me))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	json := &ctftimeJSON{
+		Standings: make([]*ctftimeStandingJSON, len(entries)),
+	}
+	for i, entry := range entries {
+		json.Standings[i] = &ctftimeStandingJSON{
+			Pos:   entry.Rank,
+			Team:  entry.Name(),
+			Score: entry.Standing.Score,
+		}
+	}
+
+	return c.JSON(http.StatusOK, json)
+}
+
+//ExportStandings the Method Handler of "GET /export/standings"
+func ExportStandings(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	entries, err := getRankingForExport(isFrozenFor(me))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	challenges, err := model.GetChallenges()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="standings.csv"`)
+	c.Response().WriteHeader(http.StatusOK)
+	w := csv.NewWriter(c.Response())
+
+	header := []string{"pos", "id", "name", "score", "solved"}
+	for _, challenge := range challenges {
+		header = append(header, challenge.Name)
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		memberIDs := map[string]struct{}{}
+		if entry.Team != nil {
+			for _, member := range entry.Team.Members {
+				if !member.IsAuthor {
+					memberIDs[member.ID] = struct{}{}
+				}
+			}
+		} else {
+			memberIDs[entry.User.ID] = struct{}{}
+		}
+
+		record := []string{strconv.Itoa(entry.Rank), entry.ID(), entry.Name(), strconv.Itoa(entry.Standing.Score), strconv.Itoa(entry.Standing.Solved)}
+		for _, challenge := range challenges {
+			solvedAt := ""
+			for _, solve := range challenge.Solves {
+				if _, ok := memberIDs[solve.UserID]; ok {
+					solvedAt = solve.CreatedAt.Format(time.RFC3339)
+					break
+				}
+			}
+			record = append(record, solvedAt)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,116 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type hintUnlockJSON struct {
+	Hint                *hintJSON `json:"hint"`
+	AlreadyOpened       bool      `json:"already_opened"`
+	DryRun              bool      `json:"dry_run"`
+	PenaltyPercent      int       `json:"penalty"`
+	TotalPenaltyPercent int       `json:"total_penalty"`
+	Cost                int       `json:"cost"`
+	Score               int       `json:"score"`
+}
+
+//openHint Open the Hint of the Challenge for Me after Checking that I can Access the Challenge
+func openHint(me *model.User, challenge *model.Challenge, hintID string, dryRun bool) (*model.HintUnlock, error) {
+	now, finish := time.Now(), model.FinishTime()
+	if !finish.After(now) && !me.IsAuthor {
+		return nil, echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("the contest has finished"))
+	}
+	if !me.IsAuthor {
+		if !challenge.IsReleased(now) {
+			return nil, echo.NewHTTPError(http.StatusNotFound)
+		}
+		progress, err := model.GetProgress(me)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if !challenge.IsUnlocked(progress) {
+			return nil, echo.NewHTTPError(http.StatusForbidden, model.ErrChallengeLocked.Error())
+		}
+	}
+
+	found := false
+	for _, hint := range challenge.Hints {
+		if hint.ID == hintID {
+			found = true
+		}
+	}
+	if !found {
+		return nil, echo.NewHTTPError(http.StatusNotFound)
+	}
+
+	unlock, err := me.OpenHint(hintID, dryRun)
+	if err != nil {
+		switch err {
+		case model.ErrHintNotFound:
+			return nil, echo.NewHTTPError(http.StatusNotFound)
+		case model.ErrHintOutOfOrder:
+			return nil, echo.NewHTTPError(http.StatusConflict, err.Error())
+		case model.ErrHintNotReleased:
+			return nil, echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !unlock.AlreadyOpened && !dryRun {
+		hintOpenEventChan <- hintOpenEvent{
+			EventName: "openHint",
+			UserID:    me.ID,
+			ProblemID: challenge.ID,
+			HintID:    hintID,
+		}
+	}
+	return unlock, nil
+}
+
+//UnlockHint the Method Handler of "POST /challenges/:challengeID/hints/:hintID/unlock"
+func UnlockHint(c echo.Context) error {
+	challengeID, hintID := c.Param("challengeID"), c.Param("hintID")
+	me := c.Get("me").(*model.User)
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return err
+	}
+
+	challenge, err := model.GetChallengeByID(challengeID)
+	if err != nil {
+		if err == model.ErrChallengeNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	unlock, err := openHint(me, challenge, hintID, dryRun)
+	if err != nil {
+		return err
+	}
+
+	opened := unlock.AlreadyOpened || !dryRun
+	json := &hintUnlockJSON{
+		Hint: &hintJSON{
+			ID:             unlock.Hint.ID,
+			Caption:        map[bool]string{true: unlock.Hint.Caption}[opened],
+			PenaltyPercent: unlock.Hint.PenaltyPercent,
+			Cost:           unlock.Hint.Cost,
+			ReleaseAt:      unlock.Hint.ReleaseAt,
+		},
+		AlreadyOpened:       unlock.AlreadyOpened,
+		DryRun:              dryRun,
+		PenaltyPercent:      map[bool]int{false: unlock.Hint.PenaltyPercent}[unlock.AlreadyOpened],
+		TotalPenaltyPercent: unlock.TotalPenaltyPercent,
+		Cost:                map[bool]int{false: unlock.Hint.Cost}[unlock.AlreadyOpened],
+		Score:               model.ApplyPenalty(challenge.CurrentScore(), unlock.TotalPenaltyPercent),
+	}
+
+	return c.JSON(http.StatusOK, json)
+}
@@ -4,18 +4,85 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"git.trapti.tech/CPCTF2019/scoreserver/model"
 	"github.com/labstack/echo"
 )
 
 type questionJSON struct {
-	ID         string    `json:"id"`
-	Questioner *userJSON `json:"questioner"`
-	Publish    bool      `json:"publish"`
-	Answerer   *userJSON `json:"answerer"`
-	Query      string    `json:"query"`
-	Answer     string    `json:"answer"`
+	ID          string                 `json:"id"`
+	Questioner  *userJSON              `json:"questioner"`
+	ChallengeID string                 `json:"challenge_id"`
+	Publish     bool                   `json:"publish"`
+	Status      string                 `json:"status"`
+	Assignee    *userJSON              `json:"assignee,omitempty"`
+	Answerer    *userJSON              `json:"answerer"`
+	Query       string                 `json:"query"`
+	Messages    []*questionMessageJSON `json:"messages"`
+	Metrics     *questionMetricsJSON   `json:"metrics,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+//putQuestionJSON the Request Body of "PUT /questions/:questionID", where the Answer of the Former API is Rejected
+type putQuestionJSON struct {
+	ChallengeID string  `json:"challenge_id"`
+	Publish     bool    `json:"publish"`
+	Status      string  `json:"status"`
+	Answer      *string `json:"answer"`
+}
+
+type questionMetricsJSON struct {
+	TimeToAssign        *int       `json:"time_to_assign"`
+	TimeToFirstResponse *int       `json:"time_to_first_response"`
+	MeanResponseTime    *int       `json:"mean_response_time"`
+	MaxResponseTime     *int       `json:"max_response_time"`
+	WaitingSince        *time.Time `json:"waiting_since"`
+	Overdue             bool       `json:"overdue"`
+}
+
+type questionMessageJSON struct {
+	ID        int       `json:"id"`
+	User      *userJSON `json:"user"`
+	Body      string    `json:"body"`
+	Broadcast bool      `json:"broadcast"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newQuestionMessageJSON(me *model.User, question *model.Question, message *model.QuestionMessage) *questionMessageJSON {
+	var user *userJSON
+	if message.User != nil && (message.UserID != question.QuestionerID || !question.Publish || me.ID == question.QuestionerID || me.IsAuthor) {
+		user = newUserJSON(me, message.User)
+	}
+	return &questionMessageJSON{
+		ID:        message.ID,
+		User:      user,
+		Body:      message.Body,
+		Broadcast: message.Broadcast,
+		CreatedAt: message.CreatedAt,
+	}
+}
+
+//durationSeconds Convert the Optional Duration into Seconds
+func durationSeconds(d *time.Duration) *int {
+	if d == nil {
+		return nil
+	}
+	seconds := int(d.Seconds())
+	return &seconds
+}
+
+func newQuestionMetricsJSON(metrics *model.QuestionMetrics) *questionMetricsJSON {
+	return &questionMetricsJSON{
+		TimeToAssign:        durationSeconds(metrics.TimeToAssign),
+		TimeToFirstResponse: durationSeconds(metrics.TimeToFirstResponse),
+		MeanResponseTime:    durationSeconds(metrics.MeanResponseTime),
+		MaxResponseTime:     durationSeconds(metrics.MaxResponseTime),
+		WaitingSince:        metrics.WaitingSince,
+		Overdue:             metrics.Overdue,
+	}
 }
 
 func newQuestionJSON(me *model.User, question *model.Question) *questionJSON {
@@ -24,17 +91,91 @@ func newQuestionJSON(me *model.User, question *model.Question) *questionJSON {
 	if question.Answerer != nil {
 		answererJSON = newUserJSON(me, question.Answerer)
 	}
+	messages := question.VisibleMessages(me)
+	messageJSONs := make([]*questionMessageJSON, len(messages))
+	for i, message := range messages {
+		messageJSONs[i] = newQuestionMessageJSON(me, question, message)
+	}
 	json := &questionJSON{
-		ID:         question.ID,
-		Questioner: map[bool]*userJSON{true: nil, false: questionerJSON}[question.Publish],
-		Publish:    question.Publish,
-		Answerer:   answererJSON,
-		Query:      question.Query,
-		Answer:     question.Answer,
+		ID:          question.ID,
+		Questioner:  map[bool]*userJSON{true: nil, false: questionerJSON}[question.Publish && me.ID != question.QuestionerID && !me.IsAuthor],
+		ChallengeID: question.ChallengeID,
+		Publish:     question.Publish,
+		Status:      question.Status,
+		Answerer:    answererJSON,
+		Query:       question.Query,
+		Messages:    messageJSONs,
+		CreatedAt:   question.CreatedAt,
+		UpdatedAt:   question.UpdatedAt,
+	}
+	if me.IsAuthor {
+		if question.Assignee != nil {
+			json.Assignee = newUserJSON(me, question.Assignee)
+		}
+		json.Metrics = newQuestionMetricsJSON(question.Metrics(time.Now()))
 	}
 	return json
 }
 
+//parseForce Parse the Query Parameter which Tells to Take Over the Question Claimed by Another Author
+func parseForce(c echo.Context) (bool, error) {
+	str := c.QueryParam("force")
+	if str == "" {
+		return false, nil
+	}
+	force, err := strconv.ParseBool(str)
+	if err != nil {
+		return false, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid force: %v", err))
+	}
+	return force, nil
+}
+
+//notifyQuestionClaim Tell All Authors Who is Handling the Question
+func notifyQuestionClaim(question *model.Question) {
+	questionEventChan <- questionEvent{
+		EventName:   "questionClaim",
+		QuestionID:  question.ID,
+		ChallengeID: question.ChallengeID,
+		Status:      question.Status,
+		UserID:      question.AssigneeID,
+		rooms:       []string{authorsRoom},
+	}
+}
+
+//notifyQuestionMessage Push the New Message to the Questioner and All Authors, or to Everyone if it is Broadcast
+func notifyQuestionMessage(question *model.Question, message *model.QuestionMessage) {
+	rooms := []string{userRoom(question.QuestionerID), authorsRoom}
+	if message.Broadcast {
+		rooms = []string{"event"}
+	}
+	questionEventChan <- questionEvent{
+		EventName:   "questionMessage",
+		QuestionID:  question.ID,
+		ChallengeID: question.ChallengeID,
+		Status:      question.Status,
+		MessageID:   message.ID,
+		UserID:      message.UserID,
+		Body:        message.Body,
+		Broadcast:   message.Broadcast,
+		rooms:       rooms,
+	}
+}
+
+//getVisibleQuestion Get the Question Record which I can See
+func getVisibleQuestion(me *model.User, questionID string) (*model.Question, error) {
+	question, err := model.GetQuestionByID(questionID)
+	if err != nil {
+		if err == model.ErrQuestionNotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound)
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if !question.IsVisibleTo(me) {
+		return nil, echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("you are not the questioner"))
+	}
+	return question, nil
+}
+
 //GetQuestions the Method Handler of "GET /questions"
 func GetQuestions(c echo.Context) error {
 	questions, err := model.GetQuestions()
@@ -42,10 +183,14 @@ func GetQuestions(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	challengeID, status := c.QueryParam("challenge_id"), c.QueryParam("status")
 	jsons := make([]*questionJSON, 0)
 	me := c.Get("me").(*model.User)
 	for _, question := range questions {
-		if question.Publish || question.QuestionerID == me.ID || me.IsAuthor {
+		if (challengeID != "" && question.ChallengeID != challengeID) || (status != "" && question.Status != status) {
+			continue
+		}
+		if question.IsVisibleTo(me) {
 			jsons = append(jsons, newQuestionJSON(me, question))
 		}
 	}
@@ -58,16 +203,9 @@ func GetQuestion(c echo.Context) error {
 	questionID := c.Param("questionID")
 	me := c.Get("me").(*model.User)
 
-	question, err := model.GetQuestionByID(questionID)
+	question, err := getVisibleQuestion(me, questionID)
 	if err != nil {
-		if err == model.ErrQuestionNotFound {
-			return echo.NewHTTPError(http.StatusNotFound)
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
-
-	if !question.Publish && question.Questioner.ID != me.ID && !me.IsAuthor {
-		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("you are not the questioner"))
+		return err
 	}
 
 	json := newQuestionJSON(me, question)
@@ -84,14 +222,22 @@ func PostQuestion(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
 	}
 
-	if me.ID != req.Questioner.ID && !me.IsAuthor {
+	questionerID := me.ID
+	if req.Questioner != nil {
+		questionerID = req.Questioner.ID
+	}
+	if me.ID != questionerID && !me.IsAuthor {
 		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("you are not the questioner"))
 	}
 
-	question, err := model.NewQuestion(req.Questioner.ID, req.Query)
+	question, err := model.NewQuestion(questionerID, req.ChallengeID, req.Query)
 	if err != nil {
+		if err == model.ErrInvalidQuestion {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	notifyQuestionMessage(question, question.Messages[0])
 
 	json := newQuestionJSON(me, question)
 
@@ -111,14 +257,163 @@ func PutQuestion(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	req := &questionJSON{}
+	req := &putQuestionJSON{}
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
+	}
+	if req.Answer != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("answers are no longer accepted here; post them to /questions/%s/messages", question.ID))
+	}
+
+	if err := question.Update(req.ChallengeID, req.Publish, req.Status); err != nil {
+		if err == model.ErrInvalidQuestion {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+//PostQuestionMessage the Method Handler of "POST /questions/:questionID/messages"
+func PostQuestionMessage(c echo.Context) error {
+	questionID := c.Param("questionID")
+	me := c.Get("me").(*model.User)
+
+	question, err := getVisibleQuestion(me, questionID)
+	if err != nil {
+		return err
+	}
+	if question.QuestionerID != me.ID && !me.IsAuthor {
+		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("you are not the questioner"))
+	}
+
+	force, err := parseForce(c)
+	if err != nil {
+		return err
+	}
+
+	req := &questionMessageJSON{}
 	if err := c.Bind(req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
 	}
 
-	if err := question.Update(req.Questioner.ID, req.Publish, req.Answerer.ID, req.Query, req.Answer); err != nil {
+	assigneeID := question.AssigneeID
+	message, err := question.PostMessage(me, req.Body, req.Broadcast, force)
+	if err != nil {
+		switch err {
+		case model.ErrInvalidQuestion:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case model.ErrQuestionClosed, model.ErrQuestionClaimed:
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	notifyQuestionMessage(question, message)
+	if question.AssigneeID != assigneeID {
+		notifyQuestionClaim(question)
+	}
+
+	json := newQuestionMessageJSON(me, question, message)
+
+	return c.JSON(http.StatusCreated, json)
+}
+
+//CloseQuestion the Method Handler of "POST /questions/:questionID/close"
+func CloseQuestion(c echo.Context) error {
+	questionID := c.Param("questionID")
+	me := c.Get("me").(*model.User)
+
+	question, err := getVisibleQuestion(me, questionID)
+	if err != nil {
+		return err
+	}
+	if question.QuestionerID != me.ID && !me.IsAuthor {
+		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("you are not the questioner"))
+	}
+
+	if err := question.Close(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+//GetQuestionQueue the Method Handler of "GET /questions/queue"
+func GetQuestionQueue(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+	mine := c.QueryParam("mine") == "true"
+
+	questions, err := model.GetQuestionQueue(time.Now())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	jsons := make([]*questionJSON, 0, len(questions))
+	for _, question := range questions {
+		if mine && question.AssigneeID != me.ID {
+			continue
+		}
+		jsons = append(jsons, newQuestionJSON(me, question))
+	}
+
+	return c.JSON(http.StatusOK, jsons)
+}
+
+//ClaimQuestion the Method Handler of "POST /questions/:questionID/claim"
+func ClaimQuestion(c echo.Context) error {
+	questionID := c.Param("questionID")
+	me := c.Get("me").(*model.User)
+
+	force, err := parseForce(c)
+	if err != nil {
+		return err
+	}
+
+	question, err := model.GetQuestionByID(questionID)
+	if err != nil {
+		if err == model.ErrQuestionNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := question.Claim(me, force); err != nil {
+		if err == model.ErrQuestionClaimed {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	notifyQuestionClaim(question)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+//UnclaimQuestion the Method Handler of "DELETE /questions/:questionID/claim"
+func UnclaimQuestion(c echo.Context) error {
+	questionID := c.Param("questionID")
+	me := c.Get("me").(*model.User)
+
+	force, err := parseForce(c)
+	if err != nil {
+		return err
+	}
+
+	question, err := model.GetQuestionByID(questionID)
+	if err != nil {
+		if err == model.ErrQuestionNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := question.Unclaim(me, force); err != nil {
+		if err == model.ErrQuestionClaimed {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	notifyQuestionClaim(question)
 
 	return c.NoContent(http.StatusNoContent)
 }
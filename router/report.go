@@ -0,0 +1,73 @@
+package router
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type incidentJSON struct {
+	Kind        string    `json:"kind"`
+	Time        time.Time `json:"time"`
+	ChallengeID string    `json:"challenge_id"`
+	UserIDs     []string  `json:"user_ids"`
+	Evidence    []string  `json:"evidence"`
+}
+
+func newIncidentJSON(incident *model.Incident) *incidentJSON {
+	json := &incidentJSON{
+		Kind:        incident.Kind,
+		Time:        incident.Time,
+		ChallengeID: incident.ChallengeID,
+		UserIDs:     incident.UserIDs,
+		Evidence:    incident.Evidence,
+	}
+	return json
+}
+
+//GetCheatingReport the Method Handler of "GET /reports/cheating"
+func GetCheatingReport(c echo.Context) error {
+	window := 10 * time.Second
+	if str := c.QueryParam("window"); str != "" {
+		seconds, err := strconv.Atoi(str)
+		if err != nil || seconds < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid window"))
+		}
+		window = time.Duration(seconds) * time.Second
+	}
+
+	incidents, err := model.GetCheatingReport(window)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to make the report: %v", err))
+	}
+
+	if c.QueryParam("format") == "csv" {
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="cheating.csv"`)
+		c.Response().WriteHeader(http.StatusOK)
+		w := csv.NewWriter(c.Response())
+		if err := w.Write([]string{"kind", "time", "challenge_id", "user_ids", "evidence"}); err != nil {
+			return err
+		}
+		for _, incident := range incidents {
+			if err := w.Write([]string{incident.Kind, incident.Time.Format(time.RFC3339), incident.ChallengeID, strings.Join(incident.UserIDs, " "), strings.Join(incident.Evidence, "\n")}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	jsons := make([]*incidentJSON, len(incidents))
+	for i, incident := range incidents {
+		jsons[i] = newIncidentJSON(incident)
+	}
+
+	return c.JSON(http.StatusOK, jsons)
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type revisionJSON struct {
+	ID        int              `json:"id"`
+	Editor    *userJSON        `json:"editor"`
+	Changes   []string         `json:"changes"`
+	Diffs     []*fieldDiffJSON `json:"diffs"`
+	Snapshot  string           `json:"snapshot"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type fieldDiffJSON struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+func newRevisionJSON(me *model.User, revision *model.Revision) *revisionJSON {
+	diffJSONs := make([]*fieldDiffJSON, len(revision.Diffs))
+	for i, diff := range revision.Diffs {
+		diffJSONs[i] = &fieldDiffJSON{
+			Field:  diff.Field,
+			Before: diff.Before,
+			After:  diff.After,
+		}
+	}
+	json := &revisionJSON{
+		ID:        revision.ID,
+		Changes:   revision.ChangedFields(),
+		Diffs:     diffJSONs,
+		Snapshot:  revision.Snapshot,
+		CreatedAt: revision.CreatedAt,
+	}
+	if revision.Editor != nil {
+		json.Editor = newUserJSON(me, revision.Editor)
+	}
+	return json
+}
+
+//GetRevisions the Method Handler of "GET /challenges/:challengeID/revisions"
+func GetRevisions(c echo.Context) error {
+	challengeID := c.Param("challengeID")
+	me := c.Get("me").(*model.User)
+
+	challenge, err := model.GetChallengeByID(challengeID)
+	if err != nil {
+		if err == model.ErrChallengeNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the challenge record: %v", err))
+	}
+
+	revisions, err := challenge.GetRevisions()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the revision records: %v", err))
+	}
+
+	jsons := make([]*revisionJSON, len(revisions))
+	for i, revision := range revisions {
+		jsons[i] = newRevisionJSON(me, revision)
+	}
+	return c.JSON(http.StatusOK, jsons)
+}
+
+//RollbackChallenge the Method Handler of "POST /challenges/:challengeID/revisions/:revisionID/rollback"
+func RollbackChallenge(c echo.Context) error {
+	challengeID := c.Param("challengeID")
+	me := c.Get("me").(*model.User)
+
+	revisionID, err := strconv.Atoi(c.Param("revisionID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound)
+	}
+	confirm, err := parseConfirm(c)
+	if err != nil {
+		return err
+	}
+
+	challenge, err := model.GetChallengeByID(challengeID)
+	if err != nil {
+		if err == model.ErrChallengeNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the challenge record: %v", err))
+	}
+
+	if impacts, err := challenge.Rollback(revisionID, me.ID, confirm); err != nil {
+		if err == model.ErrRevisionNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		if err == model.ErrUnconfirmedEdit {
+			return unconfirmedEdit(c, impacts)
+		}
+		if err == model.ErrInvalidScoring || err == model.ErrInvalidFlag || err == model.ErrInvalidPrerequisite || err == model.ErrInvalidSchedule || err == model.ErrInvalidHint || err == model.ErrInvalidEdit {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to roll back the challenge: %v", err))
+	}
+	rescheduleReleases()
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type timelineJSON struct {
+	User   *userJSON            `json:"user"`
+	Points []*timelinePointJSON `json:"points"`
+}
+
+type timelinePointJSON struct {
+	Time  time.Time `json:"time"`
+	Score int       `json:"score"`
+}
+
+func newTimelineJSON(me *model.User, timeline *model.Timeline) *timelineJSON {
+	pointJSONs := make([]*timelinePointJSON, len(timeline.Points))
+	for i, point := range timeline.Points {
+		pointJSONs[i] = &timelinePointJSON{
+			Time:  point.Time,
+			Score: point.Score,
+		}
+	}
+	json := &timelineJSON{
+		User:   newUserJSON(me, timeline.User),
+		Points: pointJSONs,
+	}
+	return json
+}
+
+//GetScoreTimelines the Method Handler of "GET /scoreboard/timeline"
+func GetScoreTimelines(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	top := 10
+	if str := c.QueryParam("top"); str != "" {
+		n, err := strconv.Atoi(str)
+		if err != nil || n <= 0 || 100 < n {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid top"))
+		}
+		top = n
+	}
+
+	frozen := isFrozenFor(me)
+	timelines, err := model.GetScoreTimelines(top, frozen)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	jsons := make([]*timelineJSON, len(timelines))
+	for i, timeline := range timelines {
+		jsons[i] = newTimelineJSON(me, timeline)
+		if frozen && timeline.User.ID != me.ID {
+			points := make([]*timelinePointJSON, 0, len(jsons[i].Points))
+			for _, point := range jsons[i].Points {
+				if model.IsVisibleWhileFrozen(point.Time) {
+					points = append(points, point)
+				}
+			}
+			jsons[i].Points = points
+		}
+	}
+
+	return c.JSON(http.StatusOK, jsons)
+}
+
+//Unfreeze the Method Handler of "POST /admin/unfreeze"
+func Unfreeze(c echo.Context) error {
+	if err := model.Unfreeze(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to unfreeze the scoreboard: %v", err))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type rankingEntryJSON struct {
+	Rank        int            `json:"rank"`
+	User        *userJSON      `json:"user"`
+	Score       int            `json:"score"`
+	Solved      int            `json:"solved"`
+	GenreScores map[string]int `json:"genre_scores"`
+	ReachedAt   time.Time      `json:"reached_at"`
+}
+
+func newRankingEntryJSON(me *model.User, entry *model.RankingEntry) *rankingEntryJSON {
+	json := &rankingEntryJSON{
+		Rank:        entry.Rank,
+		User:        newUserJSON(me, entry.User),
+		Score:       entry.Standing.Score,
+		Solved:      entry.Standing.Solved,
+		GenreScores: entry.Standing.GenreScores,
+		ReachedAt:   entry.Standing.ReachedAt,
+	}
+	json.User.Score = entry.Standing.Score
+	return json
+}
+
+//GetRanking the Method Handler of "GET /ranking"
+func GetRanking(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	var onsite *bool
+	if str := c.QueryParam("onsite"); str != "" {
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid onsite: %v", err))
+		}
+		onsite = &b
+	}
+	page, perPage := 1, 50
+	if str := c.QueryParam("page"); str != "" {
+		n, err := strconv.Atoi(str)
+		if err != nil || n <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid page"))
+		}
+		page = n
+	}
+	if str := c.QueryParam("per_page"); str != "" {
+		n, err := strconv.Atoi(str)
+		if err != nil || n <= 0 || 1000 < n {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid per_page"))
+		}
+		perPage = n
+	}
+
+	entries, err := model.GetRanking(isFrozenFor(me), onsite)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	begin, end := (page-1)*perPage, page*perPage
+	if begin > len(entries) {
+		begin = len(entries)
+	}
+	if end > len(entries) {
+		end = len(entries)
+	}
+	jsons := make([]*rankingEntryJSON, 0, end-begin)
+	for _, entry := range entries[begin:end] {
+		jsons = append(jsons, newRankingEntryJSON(me, entry))
+	}
+
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(len(entries)))
+	return c.JSON(http.StatusOK, jsons)
+}
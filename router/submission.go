@@ -0,0 +1,91 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type submissionJSON struct {
+	ID            int       `json:"id"`
+	User          *userJSON `json:"user"`
+	ChallengeID   string    `json:"challenge_id"`
+	ChallengeName string    `json:"challenge_name"`
+	Flag          string    `json:"flag"`
+	IsCorrect     bool      `json:"is_correct"`
+	FlagID        string    `json:"flag_id"`
+	Score         int       `json:"score"`
+	IP            string    `json:"ip"`
+	UserAgent     string    `json:"user_agent"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func newSubmissionJSON(me *model.User, submission *model.Submission) *submissionJSON {
+	var _userJSON *userJSON
+	if submission.User != nil {
+		_userJSON = newUserJSON(me, submission.User)
+	}
+	challengeName := ""
+	if submission.Challenge != nil {
+		challengeName = submission.Challenge.Name
+	}
+	json := &submissionJSON{
+		ID:            submission.ID,
+		User:          _userJSON,
+		ChallengeID:   submission.ChallengeID,
+		ChallengeName: challengeName,
+		Flag:          submission.Flag,
+		IsCorrect:     submission.IsCorrect,
+		FlagID:        submission.FlagID,
+		Score:         submission.Score,
+		IP:            submission.IP,
+		UserAgent:     submission.UserAgent,
+		CreatedAt:     submission.CreatedAt,
+	}
+	return json
+}
+
+//GetSubmissions the Method Handler of "GET /submissions"
+func GetSubmissions(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	var isCorrect *bool
+	if str := c.QueryParam("correct"); str != "" {
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid correct: %v", err))
+		}
+		isCorrect = &b
+	}
+	var since, until time.Time
+	if str := c.QueryParam("since"); str != "" {
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid since: %v", err))
+		}
+		since = t
+	}
+	if str := c.QueryParam("until"); str != "" {
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid until: %v", err))
+		}
+		until = t
+	}
+
+	submissions, err := model.GetSubmissions(c.QueryParam("user_id"), c.QueryParam("challenge_id"), isCorrect, since, until)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	jsons := make([]*submissionJSON, len(submissions))
+	for i, submission := range submissions {
+		jsons[i] = newSubmissionJSON(me, submission)
+	}
+
+	return c.JSON(http.StatusOK, jsons)
+}
@@ -0,0 +1,188 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type teamJSON struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	InviteCode string      `json:"invite_code"`
+	Leader     *userJSON   `json:"leader"`
+	Members    []*userJSON `json:"members"`
+	Score      int         `json:"score"`
+}
+
+func newTeamJSON(me *model.User, team *model.Team) *teamJSON {
+	var leaderJSON *userJSON
+	if team.Leader != nil {
+		leaderJSON = newUserJSON(me, team.Leader)
+	}
+	memberJSONs := make([]*userJSON, len(team.Members))
+	for i, member := range team.Members {
+		memberJSONs[i] = newUserJSON(me, member)
+	}
+	canISeeInviteCode := me.TeamID == team.ID || me.IsAuthor
+	score := team.Score
+	if me.TeamID != team.ID && isFrozenFor(me) {
+		frozenScore, err := model.GetFrozenScore(team.ID)
+		if err != nil {
+			log.Println(err)
+		}
+		score = frozenScore
+	}
+	json := &teamJSON{
+		ID:         team.ID,
+		Name:       team.Name,
+		InviteCode: map[bool]string{true: team.InviteCode}[canISeeInviteCode],
+		Leader:     leaderJSON,
+		Members:    memberJSONs,
+		Score:      score,
+	}
+	return json
+}
+
+//EnsureTeamMode Ensure the Contest is Held in Team Mode
+func EnsureTeamMode(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !model.IsTeamMode() {
+			return echo.NewHTTPError(http.StatusNotFound, "the contest is not held in team mode")
+		}
+		return next(c)
+	}
+}
+
+//GetTeams the Method Handler of "GET /teams"
+func GetTeams(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+	teams, err := model.GetTeams(isFrozenFor(me))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	jsons := make([]*teamJSON, len(teams))
+	for i, team := range teams {
+		jsons[i] = newTeamJSON(me, team)
+	}
+
+	return c.JSON(http.StatusOK, jsons)
+}
+
+//GetTeam the Method Handler of "GET /teams/:teamID"
+func GetTeam(c echo.Context) error {
+	teamID := c.Param("teamID")
+	me := c.Get("me").(*model.User)
+
+	team, err := model.GetTeamByID(teamID)
+	if err != nil {
+		if err == model.ErrTeamNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	json := newTeamJSON(me, team)
+
+	return c.JSON(http.StatusOK, json)
+}
+
+//PostTeam the Method Handler of "POST /teams"
+func PostTeam(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	req := &teamJSON{}
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
+	}
+	if req.Name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("the name is empty"))
+	}
+
+	team, err := model.NewTeam(req.Name, me)
+	if err != nil {
+		if err == model.ErrAlreadyInTeam {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	json := newTeamJSON(me, team)
+
+	c.Response().Header().Set(echo.HeaderLocation, os.Getenv("API_URL_PREFIX")+"/teams/"+team.ID)
+	return c.JSON(http.StatusCreated, json)
+}
+
+//JoinTeam the Method Handler of "POST /teams/:teamID/members"
+func JoinTeam(c echo.Context) error {
+	teamID := c.Param("teamID")
+	me := c.Get("me").(*model.User)
+
+	team, err := model.GetTeamByID(teamID)
+	if err != nil {
+		if err == model.ErrTeamNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	req := &struct {
+		InviteCode string `json:"invite_code" form:"invite_code"`
+	}{}
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to bind request body: %v", err))
+	}
+
+	if err := team.Join(me, req.InviteCode); err != nil {
+		switch err {
+		case model.ErrAlreadyInTeam, model.ErrTeamFull:
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		case model.ErrWrongInviteCode:
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+//LeaveTeam the Method Handler of "DELETE /teams/:teamID/members/:userID"
+func LeaveTeam(c echo.Context) error {
+	teamID := c.Param("teamID")
+	userID := c.Param("userID")
+	me := c.Get("me").(*model.User)
+
+	team, err := model.GetTeamByID(teamID)
+	if err != nil {
+		if err == model.ErrTeamNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if userID != me.ID && team.LeaderID != me.ID && !me.IsAuthor {
+		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("you are not the leader of the team"))
+	}
+
+	user, err := model.GetUserByID(userID, false)
+	if err != nil {
+		if err == model.ErrUserNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the user record: %v", err))
+	}
+
+	if err := team.Leave(user); err != nil {
+		if err == model.ErrNotInTeam {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.trapti.tech/CPCTF2019/scoreserver/model"
+	"github.com/labstack/echo"
+)
+
+type trashedChallengeJSON struct {
+	ID        string    `json:"id"`
+	Genre     string    `json:"genre"`
+	Name      string    `json:"name"`
+	Author    *userJSON `json:"author"`
+	Score     int       `json:"score"`
+	Solves    int       `json:"solves"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+//GetTrashedChallenges the Method Handler of "GET /trash/challenges"
+func GetTrashedChallenges(c echo.Context) error {
+	me := c.Get("me").(*model.User)
+
+	challenges, err := model.GetTrashedChallenges()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	jsons := make([]*trashedChallengeJSON, len(challenges))
+	for i, challenge := range challenges {
+		jsons[i] = &trashedChallengeJSON{
+			ID:        challenge.ID,
+			Genre:     challenge.Genre,
+			Name:      challenge.Name,
+			Author:    newUserJSON(me, challenge.Author),
+			Score:     challenge.Score,
+			Solves:    len(challenge.Solves),
+			DeletedAt: *challenge.DeletedAt,
+		}
+	}
+
+	return c.JSON(http.StatusOK, jsons)
+}
+
+//RestoreChallenge the Method Handler of "POST /trash/challenges/:challengeID/restore"
+func RestoreChallenge(c echo.Context) error {
+	challengeID := c.Param("challengeID")
+	me := c.Get("me").(*model.User)
+
+	challenge, err := model.GetTrashedChallengeByID(challengeID)
+	if err != nil {
+		if err == model.ErrChallengeNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the challenge record: %v", err))
+	}
+
+	if err := challenge.Restore(me.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	rescheduleReleases()
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+//PurgeChallenge the Method Handler of "DELETE /trash/challenges/:challengeID"
+func PurgeChallenge(c echo.Context) error {
+	challengeID := c.Param("challengeID")
+	me := c.Get("me").(*model.User)
+
+	revoke := false
+	if str := c.QueryParam("revoke"); str != "" {
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid revoke: %v", err))
+		}
+		revoke = b
+	}
+
+	challenge, err := model.GetTrashedChallengeByID(challengeID)
+	if err != nil {
+		if err == model.ErrChallengeNotFound {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the challenge record: %v", err))
+	}
+
+	if err := challenge.Purge(revoke, me.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -2,9 +2,9 @@ package router
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -19,12 +19,21 @@ type userJSON struct {
 	TwitterScreenName string `json:"twitter_screen_name"`
 	IsAuthor          bool   `json:"is_author"`
 	IsOnsite          bool   `json:"is_onsite"`
+	TeamID            string `json:"team_id"`
 	Score             int    `json:"score"`
 	WebShellPass      string `json:"web_shell_pass"`
 }
 
 func newUserJSON(me *model.User, user *model.User) *userJSON {
 	canISeePass := me.ID == user.ID || me.IsAuthor
+	score := user.Score
+	if me.ID != user.ID && isFrozenFor(me) {
+		frozenScore, err := model.GetFrozenScore(user.ID)
+		if err != nil {
+			log.Println(err)
+		}
+		score = frozenScore
+	}
 	json := &userJSON{
 		ID:                user.ID,
 		Name:              user.Name,
@@ -32,7 +41,8 @@ func newUserJSON(me *model.User, user *model.User) *userJSON {
 		TwitterScreenName: user.TwitterScreenName,
 		IsAuthor:          user.IsAuthor,
 		IsOnsite:          user.IsOnsite,
-		Score:             user.Score,
+		TeamID:            user.TeamID,
+		Score:             score,
 		WebShellPass:      map[bool]string{true: user.WebShellPass}[canISeePass],
 	}
 	return json
@@ -176,28 +186,23 @@ func CheckCode(c echo.Context) error {
 	}
 	switch {
 	case strings.HasPrefix(req.Code, "hint:"):
-		if !finish.After(now) && !me.IsAuthor {
-			return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("the contest has finished"))
-		}
 		partedCode := strings.Split(req.Code, ":")
 		if len(partedCode) != 3 {
 			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid hint code"))
 		}
-		hintID := strings.Join(partedCode[1:], ":")
-		cnt := 0
-		for _, openedHint := range me.OpenedHints {
-			if hintID == openedHint.ID {
-				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("already opened"))
-			}
-			if strings.HasPrefix(openedHint.ID, partedCode[1]+":") {
-				cnt++
+		challenge, err := model.GetChallengeByID(partedCode[1])
+		if err != nil {
+			if err == model.ErrChallengeNotFound {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid hint code"))
 			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
-		if strconv.Itoa(cnt) != partedCode[2] {
-			return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("you cannot open this hint yet"))
+		unlock, err := openHint(me, challenge, strings.Join(partedCode[1:], ":"), false)
+		if err != nil {
+			return err
 		}
-		if err := me.OpenHint(hintID); err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		if unlock.AlreadyOpened {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("already opened"))
 		}
 		return c.NoContent(http.StatusNoContent)
 	default:
@@ -218,10 +223,21 @@ func GetSolvedChallenges(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get the user record: %v", err))
 	}
 
-	solvedMap, openedMap, foundMap := makeSolvedOpenedFoundMaps(me)
-	jsons := make([]*challengeJSON, len(user.SolvedChallenges))
-	for i, challenge := range user.SolvedChallenges {
-		jsons[i] = newChallengeJSON(me, challenge, solvedMap, openedMap, foundMap)
+	frozen := me.ID != user.ID && isFrozenFor(me)
+	progress, err := model.GetProgress(me)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	solvedMap, openedMap, foundMap, err := makeSolvedOpenedFoundMaps(me)
+	if err != nil {
+		return err
+	}
+	jsons := make([]*challengeJSON, 0, len(user.SolvedChallenges))
+	for _, challenge := range user.SolvedChallenges {
+		if rank := challenge.SolveRank(user.ID); frozen && (rank < 0 || !model.IsVisibleWhileFrozen(challenge.Solves[rank].CreatedAt)) {
+			continue
+		}
+		jsons = append(jsons, newChallengeJSON(me, challenge, solvedMap, openedMap, foundMap, progress))
 	}
 
 	return c.JSON(http.StatusOK, jsons)
@@ -243,9 +259,19 @@ func GetLastSolvedChallenge(c echo.Context) error {
 	if user.LastSolvedChallengeID == "" {
 		return c.NoContent(http.StatusNoContent)
 	}
+	if me.ID != user.ID && isFrozenFor(me) && !model.IsVisibleWhileFrozen(user.LastSolvedTime) {
+		return c.NoContent(http.StatusNoContent)
+	}
 
-	solvedMap, openedMap, foundMap := makeSolvedOpenedFoundMaps(me)
-	json := newChallengeJSON(me, user.LastSolvedChallenge, solvedMap, openedMap, foundMap)
+	progress, err := model.GetProgress(me)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	solvedMap, openedMap, foundMap, err := makeSolvedOpenedFoundMaps(me)
+	if err != nil {
+		return err
+	}
+	json := newChallengeJSON(me, user.LastSolvedChallenge, solvedMap, openedMap, foundMap, progress)
 
 	c.Response().Header().Set(echo.HeaderLastModified, user.LastSolvedTime.UTC().Format(http.TimeFormat))
 	return c.JSON(http.StatusOK, json)
@@ -268,8 +294,15 @@ func GetLastSeenChallenge(c echo.Context) error {
 		return c.NoContent(http.StatusNoContent)
 	}
 
-	solvedMap, openedMap, foundMap := makeSolvedOpenedFoundMaps(me)
-	json := newChallengeJSON(me, user.LastSeenChallenge, solvedMap, openedMap, foundMap)
+	progress, err := model.GetProgress(me)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	solvedMap, openedMap, foundMap, err := makeSolvedOpenedFoundMaps(me)
+	if err != nil {
+		return err
+	}
+	json := newChallengeJSON(me, user.LastSeenChallenge, solvedMap, openedMap, foundMap, progress)
 
 	return c.JSON(http.StatusOK, json)
 }